@@ -0,0 +1,383 @@
+// Package analyzer reports direct reads and writes of struct fields that
+// have accessors generated by the accessor command, so that the accessors
+// are the only way to reach the fields once a project adopts them.
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/lazypandatg/accessor/gen"
+	"golang.org/x/tools/go/analysis"
+)
+
+const doc = `report direct access to fields that have generated accessors
+
+A read of a field whose getter is generated by accessor, or a write of a
+field whose setter is, is reported unless it is made in a method of the
+struct type itself or in the generated code. Accessors of fields declared
+in other packages are recognized too.`
+
+// Analyzer reports direct access to fields with generated accessors.
+var Analyzer = &analysis.Analyzer{
+	Name:      "accessor",
+	Doc:       doc,
+	Run:       run,
+	FactTypes: []analysis.Fact{new(accessors)},
+}
+
+// accessors is the fact recorded for a field with generated accessors.
+type accessors struct {
+	Getter string // getter method name, if generated
+	Setter string // setter or With method name, if generated
+	// Immutable is set when Setter returns a modified copy rather than
+	// assigning the field.
+	Immutable bool
+}
+
+func (*accessors) AFact() {}
+
+func (a *accessors) String() string {
+	return fmt.Sprintf("accessors(%s, %s)", a.Getter, a.Setter)
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	found := make(map[*types.Var]*accessors)
+	for _, file := range pass.Files {
+		if !gen.IsGenerated(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && fn.Body != nil {
+				recordAccessor(pass.TypesInfo, fn, found)
+			}
+		}
+	}
+	for field, acc := range found {
+		pass.ExportObjectFact(field, acc)
+	}
+
+	for _, file := range pass.Files {
+		if gen.IsGenerated(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			var recv types.Type
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+				if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					recv = obj.Type().(*types.Signature).Recv().Type()
+				}
+			}
+			checkAccess(pass, decl, recv, found)
+		}
+	}
+	return nil, nil
+}
+
+// recordAccessor records the method fn as the getter or setter of the
+// receiver's field it reaches. A getter takes no arguments and returns the
+// field, a value of its type, or a local copy of it such as the one a
+// defensive getter makes; a setter takes one value of that type and
+// assigns it to the field or stores it with a sync/atomic function. A
+// method merely mentioning the field, such as Equal(other *T) comparing a
+// *T field, is neither.
+func recordAccessor(info *types.Info, fn *ast.FuncDecl, found map[*types.Var]*accessors) {
+	obj, ok := info.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	sig := obj.Type().(*types.Signature)
+	record := func(field *types.Var, update func(acc *accessors)) {
+		acc := found[field]
+		if acc == nil {
+			acc = new(accessors)
+		}
+		update(acc)
+		found[field] = acc
+	}
+	switch {
+	case sig.Params().Len() == 0 && sig.Results().Len() == 1:
+		defs := make(map[types.Object]ast.Expr) // values of the local variables
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if assign, ok := n.(*ast.AssignStmt); ok && assign.Tok == token.DEFINE && len(assign.Lhs) == len(assign.Rhs) {
+				for i, lhs := range assign.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok && info.Defs[id] != nil {
+						defs[info.Defs[id]] = assign.Rhs[i]
+					}
+				}
+			}
+			return true
+		})
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			ret, ok := n.(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				return true
+			}
+			result := ret.Results[0]
+			if id, ok := ast.Unparen(result).(*ast.Ident); ok && defs[info.Uses[id]] != nil {
+				// out := make([]T, len(r.F)) copied from r.F.
+				result = defs[info.Uses[id]]
+			}
+			for _, field := range readFields(info, result, sig.Recv()) {
+				if !types.Identical(sig.Results().At(0).Type(), field.Type()) {
+					continue
+				}
+				record(field, func(acc *accessors) {
+					if acc.Getter == "" {
+						acc.Getter = fn.Name.Name
+					}
+				})
+			}
+			return true
+		})
+	case sig.Params().Len() == 1:
+		param := sig.Params().At(0)
+		var fields []*types.Var
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				// x.F = param
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					sel, ok := ast.Unparen(lhs).(*ast.SelectorExpr)
+					if !ok || !uses(info, n.Rhs[i], param) {
+						continue
+					}
+					if field := receiverField(info, sel, sig.Recv()); field != nil {
+						fields = append(fields, field)
+					}
+				}
+			case *ast.CallExpr:
+				// atomic.StoreInt64(&x.F, param)
+				if len(n.Args) == 2 && uses(info, n.Args[1], param) {
+					fields = append(fields, addressedFields(info, n.Args[0], sig.Recv())...)
+				}
+			}
+			return true
+		})
+		for _, field := range fields {
+			if !types.Identical(param.Type(), field.Type()) {
+				continue
+			}
+			record(field, func(acc *accessors) {
+				if acc.Setter == "" {
+					acc.Setter = fn.Name.Name
+					_, ptr := sig.Recv().Type().(*types.Pointer)
+					acc.Immutable = !ptr && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), sig.Recv().Type())
+				}
+			})
+		}
+	}
+}
+
+// readFields returns the fields of the receiver recv whose value x is
+// made of: the field x selects, or those passed to the functions and
+// conversions x calls, as in atomic.LoadInt64(&r.F) or slices.Clone(r.F).
+// A field whose method is called, as in r.F.Clone(), is left out.
+func readFields(info *types.Info, x ast.Expr, recv *types.Var) []*types.Var {
+	var fields []*types.Var
+	ast.Inspect(x, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if field := receiverField(info, sel, recv); field != nil {
+			fields = append(fields, field)
+		}
+		return false
+	})
+	return fields
+}
+
+// addressedFields returns the fields of the receiver recv whose address
+// is taken in x, as in &r.F or (*int64)(&r.F).
+func addressedFields(info *types.Info, x ast.Expr, recv *types.Var) []*types.Var {
+	var fields []*types.Var
+	ast.Inspect(x, func(n ast.Node) bool {
+		if u, ok := n.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			if sel, ok := ast.Unparen(u.X).(*ast.SelectorExpr); ok {
+				if field := receiverField(info, sel, recv); field != nil {
+					fields = append(fields, field)
+				}
+			}
+		}
+		return true
+	})
+	return fields
+}
+
+// uses reports whether x refers to the variable v.
+func uses(info *types.Info, x ast.Expr, v *types.Var) bool {
+	found := false
+	ast.Inspect(x, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == v {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// IsAccessor reports whether the method fn, declared in the package whose
+// type information is info, is the getter or setter of field, as the
+// analyzer recognizes them in the generated files.
+func IsAccessor(info *types.Info, fn *ast.FuncDecl, field *types.Var) bool {
+	found := make(map[*types.Var]*accessors)
+	recordAccessor(info, fn, found)
+	acc := found[field]
+	return acc != nil && (acc.Getter == fn.Name.Name || acc.Setter == fn.Name.Name)
+}
+
+// receiverField returns the field selected by sel when it is a field of
+// the receiver recv, possibly promoted from an embedded struct, and nil
+// otherwise.
+func receiverField(info *types.Info, sel *ast.SelectorExpr, recv *types.Var) *types.Var {
+	selection := info.Selections[sel]
+	if selection == nil || selection.Kind() != types.FieldVal {
+		return nil
+	}
+	x := ast.Unparen(sel.X)
+	for {
+		switch e := x.(type) {
+		case *ast.Ident:
+			if info.Uses[e] != recv {
+				return nil
+			}
+			return selection.Obj().(*types.Var)
+		case *ast.SelectorExpr:
+			s := info.Selections[e]
+			if s == nil || s.Kind() != types.FieldVal || !s.Obj().(*types.Var).Anonymous() {
+				return nil
+			}
+			x = ast.Unparen(e.X)
+		case *ast.StarExpr:
+			x = ast.Unparen(e.X)
+		default:
+			return nil
+		}
+	}
+}
+
+// checkAccess reports the direct reads and writes in decl of fields with
+// accessors. recv is the receiver type if decl is a method; its own fields
+// are left alone. Plain assignments and reads come with a suggested fix
+// calling the accessor instead.
+func checkAccess(pass *analysis.Pass, decl ast.Decl, recv types.Type, found map[*types.Var]*accessors) {
+	writes := make(map[ast.Expr]bool)
+	assigns := make(map[ast.Expr]*ast.AssignStmt) // single assignments by their target
+	inWrite := make(map[ast.Node]bool)            // nodes inside a write target
+	markWrite := func(x ast.Expr) {
+		x = ast.Unparen(x)
+		writes[x] = true
+		ast.Inspect(x, func(n ast.Node) bool {
+			inWrite[n] = true
+			return true
+		})
+	}
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				markWrite(lhs)
+			}
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 && n.Tok == token.ASSIGN {
+				assigns[ast.Unparen(n.Lhs[0])] = n
+			}
+		case *ast.IncDecStmt:
+			markWrite(n.X)
+		case *ast.UnaryExpr:
+			// Taking the address allows writing through it.
+			if n.Op == token.AND {
+				markWrite(n.X)
+			}
+		}
+		return true
+	})
+	ast.Inspect(decl, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		selection := pass.TypesInfo.Selections[sel]
+		if selection == nil || selection.Kind() != types.FieldVal {
+			return true
+		}
+		field := selection.Obj().(*types.Var)
+		acc := found[field]
+		if acc == nil {
+			acc = new(accessors)
+			if !pass.ImportObjectFact(field, acc) {
+				return true
+			}
+		}
+		if recv != nil && ownField(recv, field) {
+			return true
+		}
+		recvType := selection.Recv()
+		if ptr, ok := recvType.(*types.Pointer); ok {
+			recvType = ptr.Elem()
+		}
+		owner := types.TypeString(recvType, func(p *types.Package) string {
+			if p == pass.Pkg {
+				return ""
+			}
+			return p.Name()
+		})
+		var diag analysis.Diagnostic
+		switch {
+		case writes[sel] && acc.Setter != "":
+			diag.Message = fmt.Sprintf("direct write of %s.%s; use %s", owner, field.Name(), acc.Setter)
+			if assign := assigns[sel]; assign != nil && !acc.Immutable {
+				// x.F = v becomes x.SetF(v).
+				rhs := assign.Rhs[0]
+				diag.SuggestedFixes = []analysis.SuggestedFix{{
+					Message: "Call " + acc.Setter,
+					TextEdits: []analysis.TextEdit{
+						{Pos: sel.Sel.Pos(), End: rhs.Pos(), NewText: []byte(acc.Setter + "(")},
+						{Pos: rhs.End(), End: rhs.End(), NewText: []byte(")")},
+					},
+				}}
+			}
+		case !writes[sel] && acc.Getter != "":
+			diag.Message = fmt.Sprintf("direct read of %s.%s; use %s", owner, field.Name(), acc.Getter)
+			if !inWrite[sel] && addressable(sel.X) {
+				diag.SuggestedFixes = []analysis.SuggestedFix{{
+					Message: "Call " + acc.Getter,
+					TextEdits: []analysis.TextEdit{
+						{Pos: sel.Sel.Pos(), End: sel.Sel.End(), NewText: []byte(acc.Getter + "()")},
+					},
+				}}
+			}
+		default:
+			return true
+		}
+		diag.Pos = sel.Sel.Pos()
+		pass.Report(diag)
+		return true
+	})
+}
+
+// addressable reports whether x is a variable or a field of one, on which
+// a method with a pointer receiver can be called.
+func addressable(x ast.Expr) bool {
+	switch x := ast.Unparen(x).(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return addressable(x.X)
+	case *ast.StarExpr:
+		return true
+	}
+	return false
+}
+
+// ownField reports whether field is a field of the type recv, declared in
+// it or promoted from an embedded struct.
+func ownField(recv types.Type, field *types.Var) bool {
+	obj, _, _ := types.LookupFieldOrMethod(recv, true, field.Pkg(), field.Name())
+	return obj == field
+}
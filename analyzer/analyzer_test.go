@@ -0,0 +1,14 @@
+package analyzer
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestAnalyzer checks that only the generated methods reaching a field
+// count as its accessors: Equal, Root and Clone mention the parent field
+// without being its getter or setter.
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
@@ -0,0 +1,17 @@
+package a
+
+type Node struct {
+	name   string // want name:"accessors\\(GetName, SetName\\)"
+	count  int64  // want count:"accessors\\(GetCount, SetCount\\)"
+	parent *Node
+	tags   []string // want tags:"accessors\\(GetTags, \\)"
+}
+
+func use(n, m *Node) {
+	n.name = "x" // want `direct write of Node.name; use SetName`
+	_ = n.name   // want `direct read of Node.name; use GetName`
+	n.count = 1  // want `direct write of Node.count; use SetCount`
+	n.parent = m
+	_ = n.parent
+	_ = n.tags // want `direct read of Node.tags; use GetTags`
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lazypandatg/accessor/gen"
+)
+
+// runClean implements "accessor clean": it deletes the Go files written
+// by accessor, judging by their header, under the directories named by
+// args, so that the accessors can be regenerated from scratch or those of
+// deleted types removed.
+func runClean(args []string) {
+	flags := flag.NewFlagSet("clean", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "delete nothing; print the names of the files that would be deleted")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of accessor clean:\n")
+		fmt.Fprintf(os.Stderr, "\taccessor clean [-dry-run] [directories] # default .\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	failed := false
+	for _, dir := range dirs {
+		names, err := generatedFiles(strings.TrimSuffix(dir, "/..."))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range names {
+			if *dryRun {
+				fmt.Println(name)
+				continue
+			}
+			if err := os.Remove(name); err != nil {
+				log.Print(err)
+				failed = true
+				continue
+			}
+			log.Printf("removed %s", name)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// generatedFiles returns the names of the Go files under dir that were
+// written by accessor. Files named like its output but without its header
+// are reported and left alone, as they may have been edited by hand. Like
+// the go command, it skips the testdata and vendor directories and those
+// starting with . or _.
+func generatedFiles(dir string) ([]string, error) {
+	var names []string
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || !d.Type().IsRegular() {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			// Not Go source written by accessor, which always parses.
+			return nil
+		}
+		if gen.IsGenerated(file) {
+			names = append(names, path)
+		} else if outputName(name) {
+			log.Printf("%s: no accessor header, left alone", path)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// outputName reports whether name is a default output file name of
+// accessor, <type>_accessor.go or accessors_gen.go, or that of its tests.
+func outputName(name string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test"), "_external")
+	return strings.HasSuffix(name, "_accessor") || name == "accessors_gen"
+}
@@ -0,0 +1,14 @@
+// Command accessorvet reports direct access to fields that have accessors
+// generated by the accessor command. Run it on its own or through go vet:
+//
+//	go vet -vettool=$(which accessorvet) ./...
+package main
+
+import (
+	"github.com/lazypandatg/accessor/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// subcommands are the commands accessor takes as its first argument.
+var subcommands = []string{"fix", "list", "clean", "completion"}
+
+// runCompletion implements "accessor completion": it prints the completion
+// script of the shell named by args, which completes the subcommands, the
+// flags and, through "accessor completion types", the struct types of the
+// package in the current directory after -type.
+func runCompletion(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage of accessor completion:\n")
+		fmt.Fprintf(os.Stderr, "\taccessor completion bash|zsh|fish\n")
+		fmt.Fprintf(os.Stderr, "For example, in ~/.bashrc:\n")
+		fmt.Fprintf(os.Stderr, "\tsource <(accessor completion bash)\n")
+	}
+	if len(args) != 1 {
+		usage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	case "types":
+		// Called by the scripts, which discard the errors.
+		g := newGenerator()
+		if err := g.Load("."); err != nil {
+			log.Fatal(err)
+		}
+		g.SetPackage(g.Packages()[0])
+		names, err := g.StructNames()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// summary returns the usage of the flag up to the end of its first clause,
+// short enough for the completion menus.
+func summary(f *flag.Flag) string {
+	usage := f.Usage
+	if i := strings.IndexAny(usage, ";("); i > 0 {
+		usage = usage[:i]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(usage, ", e.g."))
+}
+
+func bashCompletion() string {
+	var flags, valueFlags []string
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, "-"+f.Name)
+		if !isBoolFlag(f) && f.Name != "type" {
+			valueFlags = append(valueFlags, "-"+f.Name)
+		}
+	})
+	return `# bash completion for accessor
+_accessor() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "` + strings.Join(subcommands, " ") + `" -- "$cur"))
+		[[ ${#COMPREPLY[@]} -gt 0 ]] && return
+	fi
+	if [[ ${COMP_WORDS[1]} == completion ]]; then
+		[[ $COMP_CWORD -eq 2 ]] && COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+		return
+	fi
+	case $prev in
+	-type|--type)
+		local prefix=
+		[[ $cur == *,* ]] && prefix=${cur%,*},
+		COMPREPLY=($(compgen -P "$prefix" -W "$(accessor completion types 2>/dev/null)" -- "${cur##*,}"))
+		return
+		;;
+	` + strings.Join(valueFlags, "|") + `)
+		return
+		;;
+	esac
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "` + strings.Join(flags, " ") + `" -- "$cur"))
+	fi
+}
+complete -o default -F _accessor accessor
+`
+}
+
+func zshCompletion() string {
+	var specs []string
+	flag.VisitAll(func(f *flag.Flag) {
+		desc := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(summary(f))
+		switch {
+		case f.Name == "type":
+			specs = append(specs, fmt.Sprintf("'-%s[%s]:type:_accessor_types'", f.Name, desc))
+		case isBoolFlag(f):
+			specs = append(specs, fmt.Sprintf("'-%s[%s]'", f.Name, desc))
+		default:
+			specs = append(specs, fmt.Sprintf("'-%s[%s]:%s:_files'", f.Name, desc, f.Name))
+		}
+	})
+	return fmt.Sprintf(`#compdef accessor
+# zsh completion for accessor
+
+_accessor_types() {
+	local -a types
+	types=(${(f)"$(accessor completion types 2>/dev/null)"})
+	_values -s , type $types
+}
+
+_accessor() {
+	if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+		_alternative 'commands:command:(%s)' 'directories:directory:_files -/'
+		return
+	fi
+	if [[ $words[2] == completion ]]; then
+		(( CURRENT == 3 )) && _values shell bash zsh fish
+		return
+	fi
+	_arguments \
+		%s \
+		'*:directory:_files -/'
+}
+
+compdef _accessor accessor
+`, strings.Join(subcommands, " "), strings.Join(specs, " \\\n\t\t"))
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# fish completion for accessor
+function __accessor_types
+	accessor completion types 2>/dev/null
+end
+
+complete -c accessor -n __fish_use_subcommand -a '%s'
+complete -c accessor -n '__fish_seen_subcommand_from completion' -f -a 'bash zsh fish'
+`, strings.Join(subcommands, " "))
+	flag.VisitAll(func(f *flag.Flag) {
+		desc := strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(summary(f))
+		switch {
+		case f.Name == "type":
+			fmt.Fprintf(&b, "complete -c accessor -o %s -x -a '(__fish_complete_list , __accessor_types)' -d '%s'\n", f.Name, desc)
+		case isBoolFlag(f):
+			fmt.Fprintf(&b, "complete -c accessor -o %s -d '%s'\n", f.Name, desc)
+		default:
+			fmt.Fprintf(&b, "complete -c accessor -o %s -r -d '%s'\n", f.Name, desc)
+		}
+	})
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestBashCompletionTypes completes the last type of a -type list, keeping
+// the types before it.
+func TestBashCompletionTypes(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	script := bashCompletion() + `
+accessor() { printf 'Bar\nBaz\nFoo\n'; }
+COMP_WORDS=(accessor -type Foo,Qux,Ba)
+COMP_CWORD=2
+_accessor
+printf '%s\n' "${COMPREPLY[@]}"
+`
+	out, err := exec.Command(bash, "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %s\n%s", err, out)
+	}
+	if got, want := strings.TrimSpace(string(out)), "Foo,Qux,Bar\nFoo,Qux,Baz"; got != want {
+		t.Errorf("completed\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are the names of the project configuration file looked up
+// at the module root.
+var configFiles = []string{".accessor.yaml", ".accessor.yml", ".accessor.toml"}
+
+// unconfigurableFlags select what a single run does and can't be set in
+// the configuration file.
+var unconfigurableFlags = map[string]bool{
+	"type": true, "output": true, "check": true, "dry-run": true, "list-types": true, "version": true, "json": true,
+}
+
+// findConfig returns the path of the configuration file at the root of
+// the module holding dir, or "" if there is none.
+func findConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+	var found []string
+	for _, name := range configFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			found = append(found, filepath.Join(dir, name))
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("found several configuration files: %s", strings.Join(found, ", "))
+}
+
+// loadConfig reads the configuration file name and sets the flags named
+// by its keys, except those given on the command line, which take
+// precedence. Lists are set as comma-separated values.
+func loadConfig(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	config := make(map[string]interface{})
+	if strings.HasSuffix(name, ".toml") {
+		err = toml.Unmarshal(data, &config)
+	} else {
+		err = yaml.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if flag.Lookup(key) == nil {
+			return fmt.Errorf("%s: unknown key %q", name, key)
+		}
+		if unconfigurableFlags[key] {
+			return fmt.Errorf("%s: -%s can only be given on the command line", name, key)
+		}
+		if set[key] {
+			continue
+		}
+		value := config[key]
+		if list, ok := value.([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ",")
+		}
+		if err := flag.Set(key, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("%s: %s: %s", name, key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lazypandatg/accessor/analyzer"
+	"github.com/lazypandatg/accessor/gen"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// edit replaces the bytes from Start to End of a file with Text.
+type edit struct {
+	Start, End int
+	Text       string
+}
+
+// runFix implements "accessor fix": it rewrites direct reads and writes of
+// fields with generated accessors to accessor calls, u.Name = x to
+// u.SetName(x) and u.Name to u.GetName(), in the packages named by args.
+func runFix(args []string) {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "write nothing; print a unified diff of the changes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of accessor fix:\n")
+		fmt.Fprintf(os.Stderr, "\taccessor fix [-dry-run] [packages] # default ./...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadAllSyntax}, patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		log.Fatal("the packages must compile to be fixed")
+	}
+	unfixed, err := fixPackages(pkgs, *dryRun, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if unfixed > 0 {
+		log.Printf("%d accesses left to fix by hand", unfixed)
+		os.Exit(1)
+	}
+}
+
+// fixPackages applies the suggested fixes of the analyzer to the files of
+// pkgs, or prints them as a diff to w with dryRun. A fix is applied only
+// when the method it calls is the generated accessor of the field; the
+// accesses left are reported, and counted in the result.
+func fixPackages(pkgs []*packages.Package, dryRun bool, w io.Writer) (int, error) {
+	graph, err := checker.Analyze([]*analysis.Analyzer{analyzer.Analyzer}, pkgs, nil)
+	if err != nil {
+		return 0, err
+	}
+	methods := accessorMethods(pkgs)
+
+	edits := make(map[string][]edit) // by file name
+	unfixed := 0
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return 0, act.Err
+		}
+		fset := act.Package.Fset
+		for _, diag := range act.Diagnostics {
+			if len(diag.SuggestedFixes) == 0 || !callsAccessor(act.Package, diag, methods) {
+				log.Printf("%s: %s", fset.Position(diag.Pos), diag.Message)
+				unfixed++
+				continue
+			}
+			for _, fix := range diag.SuggestedFixes {
+				for _, e := range fix.TextEdits {
+					file := fset.File(e.Pos)
+					edits[file.Name()] = append(edits[file.Name()], edit{file.Offset(e.Pos), file.Offset(e.End), string(e.NewText)})
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(edits))
+	for name := range edits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		old, err := os.ReadFile(name)
+		if err != nil {
+			return 0, err
+		}
+		src, err := format.Source(applyEdits(old, edits[name]))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %s", name, err)
+		}
+		if dryRun {
+			fmt.Fprint(w, gen.UnifiedDiff(name, name, old, src))
+			continue
+		}
+		if err := os.WriteFile(name, src, 0644); err != nil {
+			return 0, err
+		}
+	}
+	return unfixed, nil
+}
+
+// accessorMethod is a method declared in a generated file.
+type accessorMethod struct {
+	info *types.Info
+	decl *ast.FuncDecl
+}
+
+// accessorMethods returns the methods declared in the generated files of
+// pkgs and their dependencies.
+func accessorMethods(pkgs []*packages.Package) map[*types.Func]accessorMethod {
+	methods := make(map[*types.Func]accessorMethod)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, file := range pkg.Syntax {
+			if !gen.IsGenerated(file) {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil {
+					continue
+				}
+				if obj, ok := pkg.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					methods[obj] = accessorMethod{pkg.TypesInfo, fn}
+				}
+			}
+		}
+	})
+	return methods
+}
+
+// callsAccessor reports whether the suggested fix of diag, reported on the
+// field selected at diag.Pos, calls the generated accessor of that field.
+// The method the fix names could be another one, such as a hand-written
+// method of an outer struct shadowing the accessor of an embedded one.
+func callsAccessor(pkg *packages.Package, diag analysis.Diagnostic, methods map[*types.Func]accessorMethod) bool {
+	name, ok := strings.CutPrefix(diag.SuggestedFixes[0].Message, "Call ")
+	if !ok {
+		return false
+	}
+	var sel *ast.SelectorExpr
+	for _, file := range pkg.Syntax {
+		if file.FileStart <= diag.Pos && diag.Pos < file.FileEnd {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if s, ok := n.(*ast.SelectorExpr); ok && s.Sel.Pos() == diag.Pos {
+					sel = s
+				}
+				return sel == nil
+			})
+		}
+	}
+	if sel == nil {
+		return false
+	}
+	selection := pkg.TypesInfo.Selections[sel]
+	if selection == nil || selection.Kind() != types.FieldVal {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(selection.Recv(), true, pkg.Types, name)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	m, ok := methods[fn.Origin()]
+	return ok && analyzer.IsAccessor(m.info, m.decl, selection.Obj().(*types.Var).Origin())
+}
+
+// applyEdits applies the edits to src. Duplicate edits are applied once;
+// an edit overlapping an earlier one is dropped.
+func applyEdits(src []byte, edits []edit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].Start != edits[j].Start {
+			return edits[i].Start < edits[j].Start
+		}
+		return edits[i].End < edits[j].End
+	})
+	var b bytes.Buffer
+	pos := 0
+	for i, e := range edits {
+		if i > 0 && e == edits[i-1] || e.Start < pos {
+			continue
+		}
+		b.Write(src[pos:e.Start])
+		b.WriteString(e.Text)
+		pos = e.End
+	}
+	b.Write(src[pos:])
+	return b.Bytes()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// TestFixShadowed checks that accessor fix leaves a write alone when the
+// method its fix would call is not the generated accessor of the field:
+// Outer.SetName shadows the setter of the embedded Inner.Name.
+func TestFixShadowed(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/f\n\ngo 1.25\n",
+		"inner.go": `package f
+
+type Inner struct {
+	Name string
+}
+
+type Outer struct {
+	Inner
+	names []string
+}
+
+// SetName adds a name of o.
+func (o *Outer) SetName(name string) {
+	o.names = append(o.names, name)
+}
+`,
+		"inner_accessor.go": `// Code generated by "accessor"; DO NOT EDIT.
+
+package f
+
+func (i *Inner) GetName() string {
+	return i.Name
+}
+func (i *Inner) SetName(param string) {
+	i.Name = param
+}
+`,
+		"use.go": `package f
+
+func use(o *Outer) {
+	o.Name = "a"
+	o.Inner.Name = "b"
+}
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadAllSyntax, Dir: dir}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		t.Fatal("test package does not compile")
+	}
+	var out bytes.Buffer
+	unfixed, err := fixPackages(pkgs, false, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unfixed != 1 {
+		t.Errorf("%d accesses left, want 1", unfixed)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "use.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package f
+
+func use(o *Outer) {
+	o.Name = "a"
+	o.Inner.SetName("b")
+}
+`
+	if string(src) != want {
+		t.Errorf("fixed use.go:\n%s\nwant:\n%s", src, want)
+	}
+}
@@ -0,0 +1,85 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// atomicFuncs maps the basic kinds sync/atomic has Load and Store
+// functions for to the suffix of their names.
+var atomicFuncs = map[types.BasicKind]string{
+	types.Int32:   "Int32",
+	types.Int64:   "Int64",
+	types.Uint32:  "Uint32",
+	types.Uint64:  "Uint64",
+	types.Uintptr: "Uintptr",
+}
+
+// atomicTypes maps the sync/atomic types to the type of their values.
+var atomicTypes = map[string]string{
+	"Bool":    "bool",
+	"Int32":   "int32",
+	"Int64":   "int64",
+	"Uint32":  "uint32",
+	"Uint64":  "uint64",
+	"Uintptr": "uintptr",
+	"Value":   "interface{}",
+}
+
+// atomicAccessor makes the accessors of field load and store it atomically.
+// Integer fields go through the sync/atomic functions, fields of the
+// sync/atomic types through their Load and Store methods, in which case the
+// accessors take and return the value held rather than the field itself
+// and only the imports that value needs are added.
+func (g *Generator) atomicAccessor(stName string, a *accessor, field StructFieldInfo) error {
+	unsupported := func(format string, args ...interface{}) error {
+		return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name, Err: fmt.Errorf(format, args...)}
+	}
+	if a.Immutable {
+		return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+			Err: fmt.Errorf("%s fields can't have %s copies", AccessAtomic, AccessImmutable)}
+	}
+	t := g.fieldType(field)
+	if t == nil {
+		return unsupported("%s option needs a known type, got %s", AccessAtomic, field.Type)
+	}
+	x := a.Receiver + "." + a.Field
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		suffix, ok := atomicFuncs[basic.Kind()]
+		if !ok {
+			return unsupported("%s option is not supported for %s", AccessAtomic, field.Type)
+		}
+		g.addImport(stName, Import{Path: "sync/atomic"})
+		for _, imp := range field.Imports {
+			g.addImport(stName, imp)
+		}
+		if _, named := t.(*types.Named); named {
+			ptr := "(*" + basic.Name() + ")(&" + x + ")"
+			a.Load = fmt.Sprintf("%s(atomic.Load%s(%s))", a.Type, suffix, ptr)
+			a.Store = fmt.Sprintf("atomic.Store%s(%s, %s(param))", suffix, ptr, basic.Name())
+		} else {
+			a.Load = fmt.Sprintf("atomic.Load%s(&%s)", suffix, x)
+			a.Store = fmt.Sprintf("atomic.Store%s(&%s, param)", suffix, x)
+		}
+		return nil
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "sync/atomic" {
+		return unsupported("%s option is not supported for %s", AccessAtomic, field.Type)
+	}
+	if named.Obj().Name() == "Pointer" {
+		a.Type = "*" + g.typeString(stName, named.TypeArgs().At(0))
+		a.Zero = "nil"
+	} else if typ, ok := atomicTypes[named.Obj().Name()]; ok {
+		a.Type = typ
+		a.Zero = map[string]string{"bool": "false", "interface{}": "nil"}[typ]
+		if a.Zero == "" {
+			a.Zero = "0"
+		}
+	} else {
+		return unsupported("%s option is not supported for %s", AccessAtomic, field.Type)
+	}
+	a.Load = x + ".Load()"
+	a.Store = x + ".Store(param)"
+	return nil
+}
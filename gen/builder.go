@@ -0,0 +1,114 @@
+package gen
+
+import (
+	"bytes"
+	"text/template"
+)
+
+var builderTemplate = template.Must(template.New("builder").Parse(`
+// {{.Name}}Builder builds {{.Name}} values field by field.
+type {{.Name}}Builder{{.TypeParams}} struct {
+	v *{{.Type}}
+{{- range .Fields}}{{if .Required}}
+	has{{.Name}} bool
+{{- end}}{{if .ReturnsError}}
+	err{{.Name}} error
+{{- end}}{{end}}
+}
+
+// New{{.Name}}Builder returns an empty {{.Name}}Builder.
+func New{{.Name}}Builder{{.TypeParams}}() *{{.Name}}Builder{{.TypeArgs}} {
+	return &{{.Name}}Builder{{.TypeArgs}}{}
+}
+
+// value returns the {{.Name}} being built, allocating it first.
+func (b *{{.Name}}Builder{{.TypeArgs}}) value() *{{.Type}} {
+	if b.v == nil {
+		b.v = new({{.Type}})
+	}
+	return b.v
+}
+{{range .Fields}}
+// With{{.Name}} sets {{.Field}} through {{if .Immutable}}With{{.Name}}{{else}}{{.Setter}}{{end}}.
+{{- with .Deprecated}}
+//
+{{.}}
+{{- end}}
+func (b *{{$.Name}}Builder{{$.TypeArgs}}) With{{.Name}}(param {{.Type}}) *{{$.Name}}Builder{{$.TypeArgs}} {
+	v := b.value()
+{{- if .Immutable}}
+	*v = v.With{{.Name}}(param)
+{{- else if .ReturnsError}}
+	b.err{{.Name}} = v.{{.Setter}}(param)
+{{- else}}
+	v.{{.Setter}}(param)
+{{- end}}
+{{- if .Required}}
+	b.has{{.Name}} = true
+{{- end}}
+	return b
+}
+{{end}}
+// Build returns the built {{.Name}}, or an error if a required field has
+// not been set or a setter failed. The builder then starts over with an
+// empty {{.Name}}.
+func (b *{{.Name}}Builder{{.TypeArgs}}) Build() (*{{.Type}}, error) {
+{{- range .Fields}}{{if .Required}}
+	if !b.has{{.Name}} {
+		return nil, errors.New("{{$.Name}}Builder: required field {{.Field}} is not set")
+	}
+{{- end}}{{if .ReturnsError}}
+	if b.err{{.Name}} != nil {
+		return nil, b.err{{.Name}}
+	}
+{{- end}}{{end}}
+	v := b.value()
+	*b = {{.Name}}Builder{{.TypeArgs}}{}
+	return v, nil
+}
+`))
+
+// builderField is a writable field set through the builder, by its
+// setter so that its checks, hooks and change tracking apply.
+type builderField struct {
+	dynamicField
+}
+
+// genBuilder produces the <Type>Builder of the struct type, setting the
+// writable fields through their accessors. A setter returning an error
+// fails Build.
+func (g *Generator) genBuilder(st *StructInfo, fields []dynamicField) string {
+	data := struct {
+		Name       string // type name without type parameters
+		Type       string // type as instantiated with its type parameters
+		TypeParams string
+		TypeArgs   string
+		Fields     []builderField
+	}{
+		Name:       st.Name,
+		Type:       st.TypeName(),
+		TypeParams: st.TypeParamsDecl(),
+		TypeArgs:   st.TypeArgs(),
+	}
+	byKey := make(map[string]dynamicField)
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+	for _, field := range g.fields(st.Fields) {
+		f, ok := byKey[field.Name]
+		if !ok || !f.Write {
+			continue
+		}
+		if f.Load == "" && holdsLock(g.fieldType(field)) {
+			// A lock can't be passed by value.
+			continue
+		}
+		if f.Required {
+			g.addImport(st.Name, Import{Path: "errors"})
+		}
+		data.Fields = append(data.Fields, builderField{f})
+	}
+	var buf bytes.Buffer
+	builderTemplate.Execute(&buf, data)
+	return buf.String()
+}
@@ -0,0 +1,95 @@
+package gen
+
+import "testing"
+
+func TestBuilderLocks(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type Item struct{ N int }
+
+type Job struct {
+	mu      sync.Mutex           ` + "`access:\"-\"`" + `
+	Done    atomic.Bool          ` + "`access:\"r,w,atomic\"`" + `
+	Current atomic.Pointer[Item] ` + "`access:\"r,w,atomic\"`" + `
+	Name    string               ` + "`access:\"r,w,required\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Builder = true
+	src := generate(t, cfg, dir, "Job")
+	contains(t, src, "WithDone(param bool)", "WithCurrent(param *Item)", "v.SetDone(param)")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestBuild(t *testing.T) {
+	b := NewJobBuilder()
+	if _, err := b.WithDone(true).Build(); err == nil {
+		t.Error("Build without the required Name succeeded")
+	}
+	item := &Item{N: 1}
+	j, err := b.WithName("a").WithDone(true).WithCurrent(item).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.GetName() != "a" || !j.GetDone() || j.GetCurrent() != item {
+		t.Errorf("Build returned %v %v %v", j.GetName(), j.GetDone(), j.GetCurrent())
+	}
+	k, err := b.WithName("b").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k == j || k.GetDone() || j.GetName() != "a" {
+		t.Error("the builder was not reset by Build")
+	}
+}
+`)
+}
+
+func TestBuilderSetters(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	name    string          ` + "`access:\"r,w,nonempty\"`" + `
+	age     int             ` + "`access:\"r,w,min=0,max=150\"`" + `
+	changes map[string]bool ` + "`access:\"changes\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Builder = true
+	cfg.TrackChanges = true
+	cfg.Validate = ValidateError
+	src := generate(t, cfg, dir, "User")
+	runTests(t, dir, src, `package p
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	b := NewUserBuilder()
+	if _, err := b.WithName("a").WithAge(200).Build(); err == nil {
+		t.Error("Build with an age over the maximum succeeded")
+	}
+	u, err := b.WithAge(3).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetName() != "a" || u.GetAge() != 3 {
+		t.Errorf("Build returned %q %d", u.GetName(), u.GetAge())
+	}
+	if got, want := u.ChangedFields(), []string{"age", "name"}; !slices.Equal(got, want) {
+		t.Errorf("ChangedFields() = %v, want %v", got, want)
+	}
+	if _, err := b.WithName("").Build(); err == nil {
+		t.Error("Build with an empty name succeeded")
+	}
+}
+`)
+}
@@ -0,0 +1,220 @@
+package gen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"hash"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion changes with the format of the cache entries.
+const cacheVersion = "accessor cache 1"
+
+// cacheEntry is recorded for a generated package. It tells whether the
+// files of the package are up to date without type checking it.
+type cacheEntry struct {
+	Types   []string          // names of the types declared in the package
+	Outputs map[string]string // SHA-256 of the files written for the package, by name
+}
+
+// cacheSalt returns what the cache keys of a configuration have in common:
+// the configuration itself, the templates and the running executable.
+func cacheSalt(cfg Config) string {
+	cfg.Stdout = nil
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%+v\n", cacheVersion, cfg)
+	if cfg.TemplateDir != "" {
+		names, _ := filepath.Glob(filepath.Join(cfg.TemplateDir, "*.tmpl"))
+		hashFiles(h, names)
+	}
+	if exe, err := os.Executable(); err == nil {
+		if fi, err := os.Stat(exe); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", exe, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFiles writes the names and contents of the files to h, leaving out
+// the files generated by the accessor command, which are the output and
+// not the input. A file that can't be read is hashed as missing.
+func hashFiles(h hash.Hash, names []string) {
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if generatedSource(data) {
+			continue
+		}
+		fmt.Fprintf(h, "%s\n", name)
+		if err != nil {
+			fmt.Fprintf(h, "missing\n")
+			continue
+		}
+		h.Write(data)
+	}
+}
+
+// lookupCache lists the packages matching the patterns without loading
+// their syntax and adds those found in the cache, up to date, to g. It
+// returns the patterns still to be loaded, the original ones when no
+// package is in the cache.
+func (g *Generator) lookupCache(patterns []string) ([]string, error) {
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule
+	pkgs, err := packages.Load(g.loadConfig(mode), patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if g.includeTests {
+		pkgs = testVariants(pkgs)
+	}
+	g.cacheKeys = make(map[string]string)
+	var missed []string
+	hits := 0
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+		key := g.cacheKey(pkg)
+		g.cacheKeys[pkg.PkgPath] = key
+		entry := g.readCache(key)
+		if entry == nil || len(pkg.Errors) > 0 {
+			missed = append(missed, pkg.PkgPath)
+			continue
+		}
+		g.pkgs = append(g.pkgs, &Package{
+			name:   pkg.Name,
+			path:   pkg.PkgPath,
+			dir:    filepath.Dir(pkg.GoFiles[0]),
+			cached: entry,
+		})
+		hits++
+	}
+	if hits == 0 {
+		return patterns, nil
+	}
+	return missed, nil
+}
+
+// cacheKey returns the key of the cache entry of pkg. It covers the files
+// of the package and of its dependencies in the same module, and the
+// go.mod and go.sum files fixing the other dependencies.
+func (g *Generator) cacheKey(pkg *packages.Package) string {
+	var deps []*packages.Package
+	seen := make(map[*packages.Package]bool)
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		if p != pkg && (p.Module == nil || pkg.Module == nil || p.Module.Path != pkg.Module.Path) {
+			return
+		}
+		deps = append(deps, p)
+		for _, imp := range p.Imports {
+			visit(imp)
+		}
+	}
+	visit(pkg)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].PkgPath < deps[j].PkgPath })
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", g.cacheSalt, pkg.PkgPath)
+	for _, dep := range deps {
+		fmt.Fprintf(h, "package %s\n", dep.PkgPath)
+		hashFiles(h, dep.GoFiles)
+	}
+	if pkg.Module != nil && pkg.Module.GoMod != "" {
+		hashFiles(h, []string{pkg.Module.GoMod, filepath.Join(filepath.Dir(pkg.Module.GoMod), "go.sum")})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// generatedSource reports whether data is the source of a file generated
+// by the accessor command, which may start with a -header-file comment.
+func generatedSource(data []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", data, parser.PackageClauseOnly|parser.ParseComments)
+	return err == nil && IsGenerated(file)
+}
+
+// readCache returns the cache entry of the key if its files are unchanged
+// since it was recorded, and nil otherwise.
+func (g *Generator) readCache(key string) *cacheEntry {
+	data, err := os.ReadFile(filepath.Join(g.cacheDir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	entry := new(cacheEntry)
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil
+	}
+	for name, sum := range entry.Outputs {
+		if fileHash(name) != sum {
+			return nil
+		}
+	}
+	return entry
+}
+
+// fileHash returns the SHA-256 of the contents of the file name, empty if
+// it can't be read.
+func fileHash(name string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveCache records the packages generated since Load in the cache, so
+// that the next Load with the same configuration skips them while their
+// files are unchanged. It does nothing without a cache directory or with
+// Check or DryRun; packages written to the standard output are not
+// recorded.
+func (g *Generator) SaveCache() error {
+	if g.cacheDir == "" || g.check || g.dryRun {
+		return nil
+	}
+	if err := os.MkdirAll(g.cacheDir, 0755); err != nil {
+		return err
+	}
+	g.out.mu.Lock()
+	defer g.out.mu.Unlock()
+	for _, pkg := range g.pkgs {
+		outputs := g.out.byPackage[pkg]
+		if pkg.cached != nil || g.cacheKeys[pkg.path] == "" || slices.Contains(outputs, "-") {
+			continue
+		}
+		entry := cacheEntry{Outputs: make(map[string]string)}
+		for _, name := range outputs {
+			entry.Outputs[name] = fileHash(name)
+		}
+		for _, name := range pkg.types.Scope().Names() {
+			if _, ok := pkg.types.Scope().Lookup(name).(*types.TypeName); ok {
+				entry.Types = append(entry.Types, name)
+			}
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(g.cacheDir, g.cacheKeys[pkg.path]+".json"), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cached reports whether the package was found up to date in the cache by
+// Load; there is nothing to generate for it.
+func (p *Package) Cached() bool { return p.cached != nil }
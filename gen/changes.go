@@ -0,0 +1,75 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"text/template"
+)
+
+// AccessChanges marks the map[string]bool field recording the fields set
+// since the last ClearChanges, with -track-changes.
+const AccessChanges = "changes"
+
+var changesTemplate = template.Must(template.New("changes").Parse(`
+// ChangedFields returns the names of the fields set since the last
+// ClearChanges, sorted.
+func ({{.Receiver}} *{{.Struct}}) ChangedFields() []string {
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.{{if .RLock}}RLock{{else}}Lock{{end}}()
+	defer {{.Receiver}}.{{.Lock}}.{{if .RLock}}RUnlock{{else}}Unlock{{end}}()
+{{- end}}
+	fields := make([]string, 0, len({{.Receiver}}.{{.Changes}}))
+	for field := range {{.Receiver}}.{{.Changes}} {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ClearChanges forgets the fields set so far.
+func ({{.Receiver}} *{{.Struct}}) ClearChanges() {
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.Lock()
+	defer {{.Receiver}}.{{.Lock}}.Unlock()
+{{- end}}
+	{{.Receiver}}.{{.Changes}} = nil
+}
+`))
+
+// changesField returns the field of st tagged access:"changes", which must
+// be a map[string]bool. It returns an ErrMissingField error when there is
+// none.
+func (g *Generator) changesField(st *StructInfo) (string, error) {
+	return g.mapField(st, AccessChanges, "map[string]bool", "tracking changes", func(elem types.Type) bool {
+		return isBool(elem)
+	})
+}
+
+// mapField returns the field of st tagged with the option, which must be
+// a map with string keys whose element type satisfies elem, written want.
+// It returns an ErrMissingField error naming the feature needing the field
+// when there is none, and an ErrUnsupported error when it has another type.
+func (g *Generator) mapField(st *StructInfo, option, want, feature string, elem func(types.Type) bool) (string, error) {
+	for _, field := range st.Fields {
+		if !field.HasOption(option) {
+			continue
+		}
+		m, ok := g.underlying(field).(*types.Map)
+		if !ok || !isString(m.Key()) || !elem(m.Elem()) {
+			return "", &Error{Kind: ErrUnsupported, Type: st.Name, Field: field.Name,
+				Err: fmt.Errorf("%s option needs a %s, got %s", option, want, field.Type)}
+		}
+		return field.Name, nil
+	}
+	return "", &Error{Kind: ErrMissingField, Type: st.Name,
+		Err: fmt.Errorf("%s needs a %s field tagged %s:%q", feature, want, g.tagName, option)}
+}
+
+// genChanges produces the ChangedFields and ClearChanges methods of the
+// type of a, reading the Receiver, Struct, Changes and lock fields.
+func genChanges(a accessor) string {
+	var b bytes.Buffer
+	changesTemplate.Execute(&b, a)
+	return b.String()
+}
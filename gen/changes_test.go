@@ -0,0 +1,44 @@
+package gen
+
+import "testing"
+
+func TestAtomicChanges(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import "sync"
+
+type Counter struct {
+	mu      sync.Mutex
+	n       int64           ` + "`access:\"r,w,atomic\"`" + `
+	changes map[string]bool ` + "`access:\"changes\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.TrackChanges = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter")
+	runTests(t, dir, src, `package p
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSet(t *testing.T) {
+	var c Counter
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.SetN(int64(i))
+			c.ChangedFields()
+		}(i)
+	}
+	wg.Wait()
+	if got := c.ChangedFields(); len(got) != 1 || got[0] != "n" {
+		t.Errorf("ChangedFields() = %v", got)
+	}
+}
+`)
+}
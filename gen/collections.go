@@ -0,0 +1,203 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"text/template"
+)
+
+var collectionTemplate = template.Must(template.Must(template.New("collection").Parse(changedTemplate)).Parse(`
+{{- define "lock"}}
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.Lock()
+	defer {{.Receiver}}.{{.Lock}}.Unlock()
+{{- end}}
+{{- end}}
+{{- define "rlock"}}
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.{{if .RLock}}RLock{{else}}Lock{{end}}()
+	defer {{.Receiver}}.{{.Lock}}.{{if .RLock}}RUnlock{{else}}Unlock{{end}}()
+{{- end}}
+{{- end}}
+{{- define "embed"}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		{{.Receiver}}.{{.Embed}} = &{{.EmbedType}}{}
+	}
+{{- end}}
+{{- end}}
+{{- define "slice"}}
+{{- if .Write}}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) Append{{.Name}}(values ...{{.Elem}}) {
+{{- template "lock" .}}
+{{- template "embed" .}}
+	{{.Receiver}}.{{.Field}} = append({{.Receiver}}.{{.Field}}, values...)
+{{- template "changed" .}}
+}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) Remove{{.Name}}At(i int) {
+{{- template "lock" .}}
+	{{.Receiver}}.{{.Field}} = append({{.Receiver}}.{{.Field}}[:i], {{.Receiver}}.{{.Field}}[i+1:]...)
+{{- template "changed" .}}
+}
+{{- end}}
+{{- if .Read}}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) {{.Name}}At(i int) {{.Elem}} {
+{{- template "rlock" .}}
+	return {{.Receiver}}.{{.Field}}[i]
+}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) {{.Name}}Len() int {
+{{- template "rlock" .}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		return 0
+	}
+{{- end}}
+	return len({{.Receiver}}.{{.Field}})
+}
+{{- end}}
+{{- end}}
+{{- define "map"}}
+{{- if .Read}}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) Lookup{{.Name}}(key {{.Key}}) ({{.Elem}}, bool) {
+{{- template "rlock" .}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		return {{.Zero}}, false
+	}
+{{- end}}
+	v, ok := {{.Receiver}}.{{.Field}}[key]
+	return v, ok
+}
+{{- end}}
+{{- if .Write}}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) Store{{.Name}}(key {{.Key}}, v {{.Elem}}) {
+{{- template "lock" .}}
+{{- template "embed" .}}
+	if {{.Receiver}}.{{.Field}} == nil {
+		{{.Receiver}}.{{.Field}} = make({{.Type}})
+	}
+	{{.Receiver}}.{{.Field}}[key] = v
+{{- template "changed" .}}
+}
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) Delete{{.Name}}(key {{.Key}}) {
+{{- template "lock" .}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		return
+	}
+{{- end}}
+	delete({{.Receiver}}.{{.Field}}, key)
+{{- template "changed" .}}
+}
+{{- end}}
+{{- end}}`))
+
+// collection is a slice or map field with helper methods.
+type collection struct {
+	accessor
+	Key, Elem   string
+	Read, Write bool
+}
+
+// genSliceHelpers generates Append<Field> and Remove<Field>At for a writable
+// slice field, <Field>At and <Field>Len for a readable one.
+func (g *Generator) genSliceHelpers(stName string, a accessor, field StructFieldInfo, methods *methodSet) error {
+	slice, ok := g.underlying(field).(*types.Slice)
+	if !ok {
+		return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name,
+			Err: fmt.Errorf("%s option needs a slice, got %s", AccessSlice, field.Type)}
+	}
+	c := collection{
+		accessor: a,
+		Elem:     g.typeString(stName, slice.Elem()),
+		Read:     field.HasAccess(AccessRead),
+		Write:    field.HasAccess(AccessWrite),
+	}
+	var names []string
+	rename := make(map[string]string)
+	name := g.funcName(stName, a.Name)
+	if c.Write {
+		names = append(names, "Append"+a.Name, "Remove"+a.Name+"At")
+		rename["Append"+a.Name], rename["Remove"+a.Name+"At"] = "Append"+name, "Remove"+name+"At"
+	}
+	if c.Read {
+		names = append(names, a.Name+"At", a.Name+"Len")
+		rename[a.Name+"At"], rename[a.Name+"Len"] = name+"At", name+"Len"
+		methods.readers[a.Name+"At"], methods.readers[a.Name+"Len"] = true, true
+	}
+	return g.genCollection(stName, field.Name, "slice", c, names, rename, methods)
+}
+
+// genMapHelpers generates Lookup<Field> for a readable map field,
+// Store<Field> and Delete<Field> for a writable one. Store<Field> makes the
+// map when it is nil.
+func (g *Generator) genMapHelpers(stName string, a accessor, field StructFieldInfo, methods *methodSet) error {
+	m, ok := g.underlying(field).(*types.Map)
+	if !ok {
+		return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name,
+			Err: fmt.Errorf("%s option needs a map, got %s", AccessMap, field.Type)}
+	}
+	c := collection{
+		accessor: a,
+		Key:      g.typeString(stName, m.Key()),
+		Elem:     g.typeString(stName, m.Elem()),
+		Read:     field.HasAccess(AccessRead),
+		Write:    field.HasAccess(AccessWrite),
+	}
+	c.Zero = zeroOf(m.Elem(), c.Elem)
+	var names []string
+	rename := make(map[string]string)
+	name := g.funcName(stName, a.Name)
+	if c.Read {
+		names = append(names, "Lookup"+a.Name)
+		rename["Lookup"+a.Name] = "Lookup" + name
+		methods.readers["Lookup"+a.Name] = true
+	}
+	if c.Write {
+		names = append(names, "Store"+a.Name, "Delete"+a.Name)
+		rename["Store"+a.Name], rename["Delete"+a.Name] = "Store"+name, "Delete"+name
+	}
+	return g.genCollection(stName, field.Name, "map", c, names, rename, methods)
+}
+
+// genCollection declares the helper methods names of the field and prints
+// the template tpl for c, unless they are left to hand-written methods.
+// With -funcs the methods become functions named after rename.
+func (g *Generator) genCollection(stName, field, tpl string, c collection, names []string, rename map[string]string, methods *methodSet) error {
+	if ok, err := methods.declareAll(field, names...); !ok {
+		return err
+	}
+	var buf bytes.Buffer
+	collectionTemplate.ExecuteTemplate(&buf, tpl, c)
+	src := string(bytes.TrimLeft(buf.Bytes(), "\n"))
+	if g.funcs {
+		structs, _ := g.loadStructs()
+		var err error
+		if src, err = g.toFunc(structs[stName], src, rename); err != nil {
+			if _, ok := err.(*Error); ok {
+				return err
+			}
+			return &Error{Kind: ErrTemplate, Type: stName, Field: field, Err: err}
+		}
+	}
+	g.Printf(stName, "%s\n", src)
+	return nil
+}
+
+// underlying returns the underlying checked type of the field, or nil when
+// it is unknown or a type parameter.
+func (g *Generator) underlying(field StructFieldInfo) types.Type {
+	t := g.fieldType(field)
+	if _, param := t.(*types.TypeParam); t == nil || param {
+		return nil
+	}
+	return t.Underlying()
+}
@@ -0,0 +1,79 @@
+package gen
+
+import (
+	"go/token"
+	"strings"
+)
+
+// genConstructor produces New<Type>, which sets the other fields of st
+// with a default option to their default, and takes the fields tagged
+// access:"w,required" as parameters and sets them through their setters,
+// for validation and hooks to run. It returns an error too when one of the
+// setters does.
+func (g *Generator) genConstructor(st *StructInfo, fields []dynamicField) string {
+	v := g.receiverName(st)
+	taken := map[string]bool{v: true, "err": true}
+	type param struct {
+		name string
+		f    dynamicField
+	}
+	var params []param
+	var decls []string
+	returnsError := false
+	for _, f := range fields {
+		if !f.Required {
+			continue
+		}
+		name := camelCase(f.Name)
+		if token.IsKeyword(name) || taken[name] {
+			name += "Value"
+		}
+		taken[name] = true
+		params = append(params, param{name, f})
+		decls = append(decls, name+" "+f.Type)
+		returnsError = returnsError || f.ReturnsError
+	}
+
+	results, ret := "*"+st.TypeName(), v
+	if returnsError {
+		results, ret = "(*"+st.TypeName()+", error)", v+", nil"
+	}
+	w := &codeWriter{}
+	w.line("")
+	if len(params) == 0 {
+		w.line("// New%s returns a new %s with the default values.", st.Name, st.Name)
+	} else {
+		w.line("// New%s returns a new %s with the default values and the required", st.Name, st.Name)
+		w.line("// fields set.")
+	}
+	w.line("func New%s%s(%s) %s {", st.Name, st.TypeParamsDecl(), strings.Join(decls, ", "), results)
+	w.indent++
+	w.line("%s := new(%s)", v, st.TypeName())
+	for _, f := range fields {
+		if f.Default == "" || f.Required {
+			continue
+		}
+		if f.Embed != "" {
+			w.line("if %s.%s == nil {", v, f.Embed)
+			w.line("\t%s.%s = &%s{}", v, f.Embed, f.EmbedType)
+			w.line("}")
+		}
+		w.line("%s", f.assign(f.Default))
+	}
+	for _, p := range params {
+		switch {
+		case p.f.Immutable:
+			w.line("*%s = %s.With%s(%s)", v, v, p.f.Name, p.name)
+		case p.f.ReturnsError:
+			w.line("if err := %s.%s(%s); err != nil {", v, p.f.Setter, p.name)
+			w.line("\treturn nil, err")
+			w.line("}")
+		default:
+			w.line("%s.%s(%s)", v, p.f.Setter, p.name)
+		}
+	}
+	w.line("return %s", ret)
+	w.indent--
+	w.line("}")
+	return w.String()
+}
@@ -0,0 +1,293 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// genDeepCopy produces the DeepCopyInto and DeepCopy methods of the named
+// struct type in the style of the Kubernetes deepcopy-gen tool. Pointers,
+// slices, maps and arrays holding them are copied recursively; fields whose
+// type has a DeepCopyInto method, or is itself generated with -deepcopy,
+// are copied through that method. Fields tagged access:"-" are copied
+// shallowly.
+//
+// The fields are copied one by one rather than by assigning the whole
+// struct, so that those holding a lock aren't copied: the sync/atomic
+// values are copied through their Load and Store methods, the values with
+// a DeepCopyInto method through it, and mutexes and other locks are left
+// as they are in out.
+//
+// Self-referential types, which reach themselves through their fields
+// (Next *Node, Children []Node), copy through an unexported deepCopyInto
+// that records every copied *Node. A pointer met again is replaced by its
+// existing copy instead of being copied anew, so shared nodes stay shared
+// in the copy and cycles terminate. Only pointers to the type itself are
+// tracked; cycles running through other types are not.
+func (g *Generator) genDeepCopy(st *StructInfo) string {
+	dc := &deepCopier{g: g, stName: st.Name, visiting: make(map[*types.Named]bool)}
+	if named, ok := g.pkg.types.Scope().Lookup(st.Name).Type().(*types.Named); ok {
+		dc.visiting[named] = true
+		if mentions(named.Underlying(), named, g.hasDeepCopy, make(map[types.Type]bool)) {
+			dc.self = named
+		}
+	}
+
+	stName := st.TypeName()
+	w := &codeWriter{}
+	w.line("// DeepCopyInto copies in into out, deeply; in must not be nil. Locks")
+	w.line("// are left out of the copy.")
+	w.line("func (in *%s) DeepCopyInto(out *%s) {", stName, stName)
+	w.indent++
+	if dc.self != nil {
+		w.line("in.deepCopyInto(out, make(map[*%s]*%s))", stName, stName)
+		w.indent--
+		w.line("}")
+		w.line("")
+		w.line("// deepCopyInto copies in into out. Pointers to %s found in seen", st.Name)
+		w.line("// are shared with the earlier copy rather than copied again.")
+		w.line("func (in *%s) deepCopyInto(out *%s, seen map[*%s]*%s) {", stName, stName, stName, stName)
+		w.indent++
+		w.line("seen[in] = out")
+	}
+	for _, field := range st.Fields {
+		if field.Name == "_" {
+			continue
+		}
+		in, out := "in."+field.Name, "out."+field.Name
+		t := g.fieldType(field)
+		if name, ok := atomicType(t); ok {
+			if name == "Value" {
+				// Storing nil panics.
+				w.line("if v := %s.Load(); v != nil {", in)
+				w.line("	%s.Store(v)", out)
+				w.line("}")
+			} else {
+				w.line("%s.Store(%s.Load())", out, in)
+			}
+			continue
+		}
+		if holdsLock(t) {
+			if g.hasDeepCopy(t) {
+				w.line("%s.DeepCopyInto(&%s)", in, out)
+			}
+			continue
+		}
+		w.line("%s = %s", out, in)
+		if !field.Skip && t != nil && dc.needsDeepCopy(t) {
+			dc.copyValue(w, in, out, t)
+		}
+	}
+	w.indent--
+	w.line("}")
+	w.line("")
+	w.line("// DeepCopy returns a deep copy of in, nil if in is nil.")
+	w.line("func (in *%s) DeepCopy() *%s {", stName, stName)
+	w.indent++
+	w.line("if in == nil {")
+	w.line("\treturn nil")
+	w.line("}")
+	w.line("out := new(%s)", stName)
+	w.line("in.DeepCopyInto(out)")
+	w.line("return out")
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// genClone produces the Clone method of the struct type, the deep copy of
+// -clone under the name used by the standard library, as in
+// http.Header.Clone.
+func genClone(st *StructInfo, receiver string) string {
+	stName := st.TypeName()
+	return fmt.Sprintf("// Clone returns a deep copy of %s, nil if %s is nil.\nfunc (%s *%s) Clone() *%s {\n\treturn %s.DeepCopy()\n}\n",
+		receiver, receiver, receiver, stName, stName, receiver)
+}
+
+// deepCopier holds the state of generating one DeepCopyInto method.
+type deepCopier struct {
+	g      *Generator
+	stName string
+	// self is the type being copied when it is self-referential.
+	self *types.Named
+	// visiting holds the named types being expanded, so that recursive
+	// types without a DeepCopyInto method don't expand forever.
+	visiting map[*types.Named]bool
+}
+
+// mentions reports whether the type t refers to the named type target,
+// without looking into the types for which opaque holds.
+func mentions(t types.Type, target *types.Named, opaque func(types.Type) bool, seen map[types.Type]bool) bool {
+	if n, ok := t.(*types.Named); ok && n.Origin() == target {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch u := t.(type) {
+	case *types.Named:
+		if opaque(u) {
+			return false
+		}
+		return mentions(u.Underlying(), target, opaque, seen)
+	case *types.Pointer:
+		return mentions(u.Elem(), target, opaque, seen)
+	case *types.Slice:
+		return mentions(u.Elem(), target, opaque, seen)
+	case *types.Array:
+		return mentions(u.Elem(), target, opaque, seen)
+	case *types.Map:
+		return mentions(u.Elem(), target, opaque, seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if mentions(u.Field(i).Type(), target, opaque, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyValue writes the statements turning out, which holds a shallow copy
+// of in, into a deep copy of it. Both expressions must be addressable
+// values of type t.
+func (dc *deepCopier) copyValue(w *codeWriter, in, out string, t types.Type) {
+	g := dc.g
+	if dc.isSelf(t) {
+		w.line("%s.deepCopyInto(&%s, seen)", in, out)
+		return
+	}
+	if g.hasDeepCopy(t) {
+		w.line("%s.DeepCopyInto(&%s)", in, out)
+		return
+	}
+	if named, ok := t.(*types.Named); ok {
+		if dc.visiting[named] {
+			// A recursive type without DeepCopyInto stays shallow.
+			return
+		}
+		dc.visiting[named] = true
+		defer delete(dc.visiting, named)
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		w.line("if %s != nil {", in)
+		w.indent++
+		w.line("in, out := &%s, &%s", in, out)
+		switch {
+		case dc.isSelf(u.Elem()):
+			w.line("if c, ok := seen[*in]; ok {")
+			w.line("\t*out = c")
+			w.line("} else {")
+			w.line("\t*out = new(%s)", g.typeString(dc.stName, u.Elem()))
+			w.line("\t(*in).deepCopyInto(*out, seen)")
+			w.line("}")
+		case g.hasDeepCopy(u.Elem()):
+			w.line("*out = new(%s)", g.typeString(dc.stName, u.Elem()))
+			w.line("(*in).DeepCopyInto(*out)")
+		default:
+			w.line("*out = new(%s)", g.typeString(dc.stName, u.Elem()))
+			w.line("**out = **in")
+			if dc.needsDeepCopy(u.Elem()) {
+				dc.copyValue(w, "(**in)", "(**out)", u.Elem())
+			}
+		}
+		w.indent--
+		w.line("}")
+	case *types.Slice:
+		w.line("if %s != nil {", in)
+		w.indent++
+		w.line("in, out := &%s, &%s", in, out)
+		w.line("*out = make(%s, len(*in))", g.typeString(dc.stName, t))
+		w.line("copy(*out, *in)")
+		if dc.needsDeepCopy(u.Elem()) {
+			w.line("for i := range *in {")
+			w.indent++
+			dc.copyValue(w, "(*in)[i]", "(*out)[i]", u.Elem())
+			w.indent--
+			w.line("}")
+		}
+		w.indent--
+		w.line("}")
+	case *types.Map:
+		w.line("if %s != nil {", in)
+		w.indent++
+		w.line("in, out := &%s, &%s", in, out)
+		w.line("*out = make(%s, len(*in))", g.typeString(dc.stName, t))
+		w.line("for key, val := range *in {")
+		w.indent++
+		if dc.needsDeepCopy(u.Elem()) {
+			w.line("outVal := val")
+			dc.copyValue(w, "val", "outVal", u.Elem())
+			w.line("(*out)[key] = outVal")
+		} else {
+			w.line("(*out)[key] = val")
+		}
+		w.indent--
+		w.line("}")
+		w.indent--
+		w.line("}")
+	case *types.Array:
+		w.line("for i := range %s {", in)
+		w.indent++
+		dc.copyValue(w, in+"[i]", out+"[i]", u.Elem())
+		w.indent--
+		w.line("}")
+	}
+}
+
+// isSelf reports whether t is the self-referential type being copied.
+func (dc *deepCopier) isSelf(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	return ok && dc.self != nil && n.Origin() == dc.self
+}
+
+// needsDeepCopy reports whether a shallow copy of a value of type t may
+// share memory with the original.
+func (dc *deepCopier) needsDeepCopy(t types.Type) bool {
+	if dc.isSelf(t) || dc.g.hasDeepCopy(t) {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map:
+		return true
+	case *types.Array:
+		return dc.needsDeepCopy(u.Elem())
+	}
+	return false
+}
+
+// hasDeepCopy reports whether values of type t are copied through a
+// DeepCopyInto method, either declared already or generated in this run.
+func (g *Generator) hasDeepCopy(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	if named.Obj().Pkg() == g.pkg.types && g.deepCopy[named.Obj().Name()] {
+		return true
+	}
+	if _, ok := named.Underlying().(*types.Pointer); ok {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, named.Obj().Pkg(), "DeepCopyInto")
+	_, ok = obj.(*types.Func)
+	return ok
+}
+
+// codeWriter accumulates indented lines of generated code.
+type codeWriter struct {
+	bytes.Buffer
+	indent int
+}
+
+func (w *codeWriter) line(format string, args ...interface{}) {
+	if format != "" {
+		w.WriteString(strings.Repeat("\t", w.indent))
+	}
+	fmt.Fprintf(w, format, args...)
+	w.WriteByte('\n')
+}
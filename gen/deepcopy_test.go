@@ -0,0 +1,66 @@
+package gen
+
+import "testing"
+
+func TestDeepCopy(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type Item struct {
+	Name string
+}
+
+type Foo struct {
+	mu      sync.Mutex   ` + "`access:\"-\"`" + `
+	count   atomic.Int64 ` + "`access:\"-\"`" + `
+	Items   []Item
+	Ptr     *Item
+	Labels  map[string][]string
+	Skipped []int ` + "`access:\"-\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.DeepCopy = true
+	src := generate(t, cfg, dir, "Foo", "Item")
+	contains(t, src, "// DeepCopyInto copies in into out", "// DeepCopy returns a deep copy of in", "out.count.Store(in.count.Load())")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestDeepCopy(t *testing.T) {
+	in := &Foo{
+		Items:   []Item{{"a"}},
+		Ptr:     &Item{"p"},
+		Labels:  map[string][]string{"k": {"v"}},
+		Skipped: []int{1},
+	}
+	in.count.Store(3)
+	in.mu.Lock()
+	out := in.DeepCopy()
+	in.mu.Unlock()
+	out.Items[0].Name = "b"
+	out.Ptr.Name = "q"
+	out.Labels["k"][0] = "w"
+	out.Labels["new"] = nil
+	if in.Items[0].Name != "a" || in.Ptr.Name != "p" || in.Labels["k"][0] != "v" || len(in.Labels) != 1 {
+		t.Errorf("the copy shares memory with the original: %+v", in)
+	}
+	if out.count.Load() != 3 {
+		t.Errorf("count = %d, want 3", out.count.Load())
+	}
+	if !out.mu.TryLock() {
+		t.Error("the lock was copied")
+	}
+	if &out.Skipped[0] != &in.Skipped[0] {
+		t.Error("the field tagged access:\"-\" was deep copied")
+	}
+	if (*Foo)(nil).DeepCopy() != nil {
+		t.Error("DeepCopy of nil is not nil")
+	}
+}
+`)
+}
@@ -0,0 +1,98 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessDefault is the key of the option giving the value the constructor
+// and Reset set a field to, as in access:"w,default=10".
+const AccessDefault = "default"
+
+// defaultValue returns the Go expression of the default value of the
+// field, written in the output of stName: value quoted for a string,
+// checked for a number or bool, and written in the largest unit dividing
+// a duration such as 90s, as 90 * time.Second or 1500 * time.Millisecond,
+// for time.Duration. The value of an atomic type such as atomic.Int64 is
+// that of its Load method.
+func (g *Generator) defaultValue(stName string, field StructFieldInfo, value string) (string, error) {
+	unsupported := func(format string, args ...interface{}) error {
+		return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name, Err: fmt.Errorf(format, args...)}
+	}
+	invalid := func(format string, args ...interface{}) error {
+		return &Error{Kind: ErrInvalidOption, Type: stName, Field: field.Name, Err: fmt.Errorf(format, args...)}
+	}
+	t := g.fieldType(field)
+	if t != nil && field.HasOption(AccessAtomic) {
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			t = g.loadType(t)
+		}
+	}
+	if t == nil {
+		return "", unsupported("%s needs a known type", AccessDefault)
+	}
+	if named, ok := types.Unalias(t).(*types.Named); ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", invalid("%s=%s: want a duration", AccessDefault, value)
+		}
+		return durationExpr(strings.TrimSuffix(g.typeString(stName, named), "Duration"), d), nil
+	}
+	basic, _ := t.Underlying().(*types.Basic)
+	switch {
+	case basic == nil:
+	case basic.Info()&types.IsString != 0:
+		return strconv.Quote(value), nil
+	case basic.Info()&types.IsBoolean != 0:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", invalid("%s=%s: want a bool", AccessDefault, value)
+		}
+		return strconv.FormatBool(b), nil
+	case basic.Info()&(types.IsInteger|types.IsFloat) != 0:
+		if err := parseBound(value, basic); err != nil {
+			return "", invalid("%s=%s: %s", AccessDefault, value, err)
+		}
+		return value, nil
+	}
+	return "", unsupported("%s needs a string, number, bool or time.Duration, got %s", AccessDefault, field.Type)
+}
+
+// durationUnits are the constants of the time package durations are
+// written with, from the largest.
+var durationUnits = []struct {
+	name string
+	d    time.Duration
+}{
+	{"Hour", time.Hour}, {"Minute", time.Minute}, {"Second", time.Second},
+	{"Millisecond", time.Millisecond}, {"Microsecond", time.Microsecond}, {"Nanosecond", time.Nanosecond},
+}
+
+// durationExpr returns the Go expression of d as a multiple of the largest
+// unit dividing it, qualified by the prefix of the time package.
+func durationExpr(prefix string, d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	for _, unit := range durationUnits {
+		if d%unit.d == 0 {
+			return fmt.Sprintf("%d * %s%s", d/unit.d, prefix, unit.name)
+		}
+	}
+	panic("unreachable")
+}
+
+// loadType returns the type of the value loaded by the Load method of the
+// atomic type t, or nil if it has none.
+func (g *Generator) loadType(t types.Type) types.Type {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, g.pkg.types, "Load")
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Signature().Results().Len() != 1 {
+		return nil
+	}
+	return fn.Signature().Results().At(0).Type()
+}
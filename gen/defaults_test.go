@@ -0,0 +1,58 @@
+package gen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationExpr(t *testing.T) {
+	for _, test := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0"},
+		{90 * time.Second, "90 * time.Second"},
+		{time.Hour + 30*time.Minute, "90 * time.Minute"},
+		{2 * time.Hour, "2 * time.Hour"},
+		{1500 * time.Millisecond, "1500 * time.Millisecond"},
+		{-5 * time.Second, "-5 * time.Second"},
+		{7, "7 * time.Nanosecond"},
+	} {
+		if got := durationExpr("time.", test.d); got != test.want {
+			t.Errorf("durationExpr(%s) = %s, want %s", test.d, got, test.want)
+		}
+	}
+}
+
+func TestDurationDefault(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import "time"
+
+type Timeout = time.Duration
+
+type Client struct {
+	timeout Timeout       ` + "`access:\"r,w,default=1m30s\"`" + `
+	retry   time.Duration ` + "`access:\"r,w,default=250ms\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Reset = true
+	src := generate(t, cfg, dir, "Client")
+	contains(t, src, "90 * time.Second", "250 * time.Millisecond")
+	runTests(t, dir, src, `package p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReset(t *testing.T) {
+	var c Client
+	c.Reset()
+	if c.GetTimeout() != 90*time.Second || c.GetRetry() != 250*time.Millisecond {
+		t.Errorf("Reset left %v %v", c.GetTimeout(), c.GetRetry())
+	}
+}
+`)
+}
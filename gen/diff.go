@@ -0,0 +1,165 @@
+package gen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each change.
+const diffContext = 3
+
+// edit is a line of a diff: op is ' ' for a line kept, '-' for a line
+// removed from the old text and '+' for a line added by the new one.
+type edit struct {
+	op   byte
+	line string
+}
+
+// UnifiedDiff returns the unified diff turning the text a, named
+// fromName, into b, named toName, or "" when they are equal.
+func UnifiedDiff(fromName, toName string, a, b []byte) string {
+	edits := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change and the changes close enough to it to
+		// share its hunk.
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		last := first
+		for k := first; k < len(edits) && k <= last+2*diffContext+1; k++ {
+			if edits[k].op != ' ' {
+				last = k
+			}
+		}
+		from, to := max(first-diffContext, start), min(last+1+diffContext, len(edits))
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		writeHunk(&out, edits, from, to)
+		start = to
+	}
+	return out.String()
+}
+
+// diffLines returns the shortest edit script turning the lines x into y,
+// found with Myers' algorithm in O((n+m)·d) time for d differing lines.
+func diffLines(x, y []string) []edit {
+	n, m := len(x), len(y)
+	// v[offset+k] is the furthest line of x reached on the diagonal k,
+	// where k is the line of x minus the line of y. trace[d] holds the
+	// diagonals -d to d of v before the step d, for walking back.
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; ; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			// Reach the diagonal k from k+1, adding a line of y, or from
+			// k-1, removing a line of x, whichever got further.
+			var i int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+			if i >= n && j >= m {
+				return backtrack(x, y, trace, d)
+			}
+		}
+	}
+}
+
+// backtrack walks the steps of diffLines back from the ends of x and y,
+// reached in d steps, and returns the edits in order.
+func backtrack(x, y []string, trace [][]int, d int) []edit {
+	var edits []edit
+	i, j := len(x), len(y)
+	for ; d > 0; d-- {
+		v, k := trace[d], i-j
+		// v holds the diagonals -d to d.
+		at := func(k int) int { return v[k+d] }
+		var prev int
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prev = k + 1
+		} else {
+			prev = k - 1
+		}
+		prevI := at(prev)
+		for i > prevI && j > prevI-prev {
+			i--
+			j--
+			edits = append(edits, edit{' ', x[i]})
+		}
+		if prev == k+1 {
+			j--
+			edits = append(edits, edit{'+', y[j]})
+		} else {
+			i--
+			edits = append(edits, edit{'-', x[i]})
+		}
+	}
+	for i > 0 {
+		i--
+		edits = append(edits, edit{' ', x[i]})
+	}
+	slices.Reverse(edits)
+	return edits
+}
+
+// writeHunk writes the hunk of the edits [from, to).
+func writeHunk(b *strings.Builder, edits []edit, from, to int) {
+	var aStart, bStart int // lines of a and b before the hunk
+	for _, e := range edits[:from] {
+		if e.op != '+' {
+			aStart++
+		}
+		if e.op != '-' {
+			bStart++
+		}
+	}
+	var aLen, bLen int
+	for _, e := range edits[from:to] {
+		if e.op != '+' {
+			aLen++
+		}
+		if e.op != '-' {
+			bLen++
+		}
+	}
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, e := range edits[from:to] {
+		b.WriteByte(e.op)
+		b.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits s into lines, each keeping its newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,48 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+\ No newline at end of file
+`
+	if got := UnifiedDiff("old", "new", []byte(a), []byte(b)); got != want {
+		t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := UnifiedDiff("old", "new", []byte(a), []byte(a)); got != "" {
+		t.Errorf("UnifiedDiff of equal texts =\n%s", got)
+	}
+}
+
+func TestUnifiedDiffLarge(t *testing.T) {
+	// A table of the common subsequences of these texts would hold 10^10
+	// entries.
+	var a, b strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	got := UnifiedDiff("old", "new", []byte("first\n"+a.String()), []byte(b.String()+"last\n"))
+	if strings.Count(got, "@@ ") != 2 || !strings.Contains(got, "\n-first\n") || !strings.Contains(got, "\n+last\n") {
+		t.Errorf("UnifiedDiff =\n%s", got)
+	}
+}
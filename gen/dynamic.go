@@ -0,0 +1,234 @@
+package gen
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// dynamicField is a field reached by name through its accessors, by the
+// ToMap, FromMap, GetField, SetField, Merge and Apply methods and the
+// constructor, named by the
+// <Type>Field constants and described by the Fields method.
+type dynamicField struct {
+	accessor
+	Key         string // name of the field, the key of its value
+	Read, Write bool
+	NonZero     string // condition testing the value v of the field against the zero value, with -merge
+	JSON        string // key of the field in the json tag, if any
+	Required    bool   // a parameter of the constructor
+	Default     string // value given by the default option, if any
+}
+
+// Resettable reports whether Reset sets the field to its zero value: it
+// is writable and not an atomic.Value, which can't store nil.
+func (f dynamicField) Resettable() bool {
+	return f.Write && !(f.Load != "" && f.Type == "interface{}")
+}
+
+// ResetStmt returns the statement setting the field to its default or
+// zero value.
+func (f dynamicField) ResetStmt() string {
+	if f.Default != "" {
+		return f.assign(f.Default)
+	}
+	return f.assign(f.Zero)
+}
+
+// assign returns the statement setting the field to value, atomically if
+// it is accessed so.
+func (f dynamicField) assign(value string) string {
+	if f.Store != "" {
+		i := strings.LastIndex(f.Store, "param")
+		return f.Store[:i] + value + f.Store[i+len("param"):]
+	}
+	return f.Receiver + "." + f.Field + " = " + value
+}
+
+// dynamicMethods is the template data of the methods reaching the fields
+// by name.
+type dynamicMethods struct {
+	Receiver, Struct, Name string
+	TypeParams, TypeArgs   string
+	Fields                 []dynamicField
+	Lock                   string // mutex field the methods modifying the fields lock
+}
+
+var dynamicTemplate = template.Must(template.New("dynamic").Parse(`
+{{- define "assert"}}
+		param, ok := v.({{.Type}})
+		if !ok {
+			return fmt.Errorf("{{.Struct}}.{{.Key}}: got %T, want {{.Type}}", v)
+		}
+{{- end}}
+{{- define "set"}}
+	{{- if .Immutable}}
+		*{{.Receiver}} = {{.Receiver}}.With{{.Name}}(param)
+	{{- else if .ReturnsError}}
+		if err := {{.Receiver}}.{{.Setter}}(param); err != nil {
+			return err
+		}
+	{{- else}}
+		{{.Receiver}}.{{.Setter}}(param)
+	{{- end}}
+{{- end}}
+{{- define "maps"}}
+// ToMap returns the readable fields of {{.Receiver}} by name.
+func ({{.Receiver}} *{{.Struct}}) ToMap() map[string]interface{} {
+	if {{.Receiver}} == nil {
+		return nil
+	}
+	return map[string]interface{}{
+{{- range .Fields}}{{if .Read}}
+		{{printf "%q" .Key}}: {{.Receiver}}.{{.Getter}}(),
+{{- end}}{{end}}
+	}
+}
+
+// FromMap sets the writable fields of {{.Receiver}} found in values, which
+// must hold values of the type of the field. It stops at the first value
+// of another type, or the first error of a setter.
+func ({{.Receiver}} *{{.Struct}}) FromMap(values map[string]interface{}) error {
+{{- range .Fields}}{{if .Write}}
+	if v, ok := values[{{printf "%q" .Key}}]; ok {
+	{{- template "assert" .}}
+	{{- template "set" .}}
+	}
+{{- end}}{{end}}
+	return nil
+}
+{{- end}}
+{{- define "names"}}
+// {{.Name}}Field is the name of a field of {{.Name}}.
+type {{.Name}}Field string
+
+// Names of the fields of {{.Name}} with accessors.
+const (
+{{- range .Fields}}
+	{{$.Name}}Field{{.Name}} {{$.Name}}Field = {{printf "%q" .Key}}
+{{- end}}
+)
+{{- end}}
+{{- define "fields"}}
+// Fields describes the fields of {{.Name}} with accessors. The elements
+// have the type meta.FieldInfo of github.com/lazypandatg/accessor/meta,
+// spelled out so that the package needn't be imported.
+func (*{{.Struct}}) Fields() []struct {
+	Name     string
+	Type     string
+	Readable bool
+	Writable bool
+} {
+	return []struct {
+		Name     string
+		Type     string
+		Readable bool
+		Writable bool
+	}{
+{{- range .Fields}}
+		{Name: {{printf "%q" .Key}}, Type: {{printf "%q" .Type}}, Readable: {{.Read}}, Writable: {{.Write}}},
+{{- end}}
+	}
+}
+{{- end}}
+{{- define "reset"}}
+// Reset sets the writable fields of {{.Receiver}} to their default or zero values.
+func ({{.Receiver}} *{{.Struct}}) Reset() {
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.Lock()
+	defer {{.Receiver}}.{{.Lock}}.Unlock()
+{{- end}}
+{{- range .Fields}}{{if .Resettable}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} != nil {
+		{{.ResetStmt}}
+	}
+{{- else}}
+	{{.ResetStmt}}
+{{- end}}
+{{- end}}{{end}}
+}
+{{- end}}
+{{- define "merge"}}
+// Merge sets the readable and writable fields of {{.Receiver}} to those of other
+// that are not zero, stopping at the first error of a setter.
+func ({{.Receiver}} *{{.Struct}}) Merge(other *{{.Struct}}) error {
+	if other == nil {
+		return nil
+	}
+{{- range .Fields}}{{if and .Read .Write}}
+	if param := other.{{.Getter}}(); {{.NonZero}} {
+	{{- template "set" .}}
+	}
+{{- end}}{{end}}
+	return nil
+}
+{{- end}}
+{{- define "patch"}}
+// {{.Name}}Patch holds new values of the writable fields of {{.Name}}, nil
+// for those left alone.
+type {{.Name}}Patch{{.TypeParams}} struct {
+{{- range .Fields}}{{if .Write}}
+	{{.Name}} *{{.Type}}{{if .JSON}} ` + "`" + `json:"{{.JSON}},omitempty"` + "`" + `{{end}}
+{{- end}}{{end}}
+}
+
+// Apply sets the fields of {{.Receiver}} given by patch, stopping at the first
+// error of a setter.
+func ({{.Receiver}} *{{.Struct}}) Apply(patch {{.Name}}Patch{{.TypeArgs}}) error {
+{{- range .Fields}}{{if .Write}}
+	if patch.{{.Name}} != nil {
+		param := *patch.{{.Name}}
+	{{- template "set" .}}
+	}
+{{- end}}{{end}}
+	return nil
+}
+{{- end}}
+{{- define "byname"}}
+// GetField returns the value of the readable field name of {{.Receiver}}, and
+// false if there is none.
+func ({{.Receiver}} *{{.Struct}}) GetField(name string) (interface{}, bool) {
+	switch name {
+{{- range .Fields}}{{if .Read}}
+	case {{printf "%q" .Key}}:
+		return {{.Receiver}}.{{.Getter}}(), true
+{{- end}}{{end}}
+	}
+	return nil, false
+}
+
+// SetField sets the writable field name of {{.Receiver}} to v, which must be
+// of the type of the field, returning the error of the setter if any.
+func ({{.Receiver}} *{{.Struct}}) SetField(name string, v interface{}) error {
+	switch name {
+{{- range .Fields}}{{if .Write}}
+	case {{printf "%q" .Key}}:
+	{{- template "assert" .}}
+	{{- template "set" .}}
+{{- end}}{{end}}
+	default:
+		return fmt.Errorf("{{.Name}} has no writable field %q", name)
+	}
+	return nil
+}
+{{- end}}`))
+
+// genDynamic executes the dynamic template name for the fields of st,
+// whose thread-safe accessors lock mu if not nil.
+func (g *Generator) genDynamic(name string, st *StructInfo, fields []dynamicField, mu *lock) string {
+	data := dynamicMethods{
+		Receiver:   g.receiverName(st),
+		Struct:     st.TypeName(),
+		Name:       st.Name,
+		TypeParams: st.TypeParamsDecl(),
+		TypeArgs:   st.TypeArgs(),
+		Fields:     fields,
+	}
+	if mu != nil {
+		data.Lock = mu.Field
+	}
+	var b bytes.Buffer
+	dynamicTemplate.ExecuteTemplate(&b, name, data)
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	Name  string ` + "`access:\"r,w\"`" + `
+	email string ` + "`access:\"r\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Fields = true
+	src := generate(t, cfg, dir, "User")
+	if strings.Contains(src, `"github.com/lazypandatg/accessor/meta"`) {
+		t.Errorf("generated code imports the meta package:\n%s", src)
+	}
+	runTests(t, dir, src, `package p
+
+import (
+	"testing"
+
+	"github.com/lazypandatg/accessor/meta"
+)
+
+func TestFields(t *testing.T) {
+	var fields []meta.FieldInfo = (*User)(nil).Fields()
+	want := []meta.FieldInfo{
+		{Name: "Name", Type: "string", Readable: true, Writable: true},
+		{Name: "email", Type: "string", Readable: true},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("Fields() = %v, want %v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("Fields()[%d] = %v, want %v", i, fields[i], want[i])
+		}
+	}
+}
+`)
+}
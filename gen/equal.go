@@ -0,0 +1,318 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// equalDirective in the doc comment of a type generates its Equal method
+// without -equal.
+const equalDirective = "//accessor:equal"
+
+// genEqual produces the Equal(other *T) bool method of the struct type,
+// comparing its fields one by one: == for comparable types, the Equal
+// method of types having one such as time.Time, element by element for
+// slices, maps and arrays, and the pointed to values for pointers. Slices
+// and maps of the same length are equal whether they are nil or not.
+// Interfaces and what can't be compared otherwise go through
+// reflect.DeepEqual. Only the valueFields are compared.
+//
+// Self-referential types, which reach themselves through their fields
+// (Next *Node, Children []Node), compare through an unexported equal that
+// records the pairs of *Node being compared. A pair met again while it is
+// compared is taken as equal, as reflect.DeepEqual does, so that cycles
+// terminate. Only the type itself is tracked; cycles running through other
+// types are not.
+//
+// Atomic fields are compared by their loaded values. When the accessors
+// lock, each operand is copied under its read lock in turn and the copies
+// compared, so that no two values are ever locked together.
+func (g *Generator) genEqual(st *StructInfo, receiver string) string {
+	e := &equaler{g: g, stName: st.Name}
+	if named, ok := g.pkg.types.Scope().Lookup(st.Name).Type().(*types.Named); ok {
+		opaque := func(t types.Type) bool {
+			_, ok := e.equalMethod(t)
+			return ok
+		}
+		if mentions(named.Underlying(), named, opaque, make(map[types.Type]bool)) {
+			e.self = named
+		}
+	}
+	stName := st.TypeName()
+	w := &codeWriter{}
+	w.line("// Equal reports whether %s and other hold equal fields.", receiver)
+	w.line("func (%s *%s) Equal(other *%s) bool {", receiver, stName, stName)
+	w.indent++
+	if e.self != nil {
+		w.line("return %s.equal(other, make(map[[2]*%s]bool))", receiver, stName)
+		w.indent--
+		w.line("}")
+		w.line("")
+		w.line("// equal reports whether %s and other hold equal fields. Pairs found in", receiver)
+		w.line("// visited are being compared already and taken as equal.")
+		w.line("func (%s *%s) equal(other *%s, visited map[[2]*%s]bool) bool {", receiver, stName, stName, stName)
+		w.indent++
+	}
+	mu := g.structLock(st)
+	if mu != nil {
+		w.line("if %s == other {", receiver)
+		w.line("\treturn true")
+		w.line("}")
+	}
+	w.line("if %s == nil || other == nil {", receiver)
+	w.line("\treturn %s == other", receiver)
+	w.line("}")
+	if e.self != nil {
+		w.line("pair := [2]*%s{%s, other}", stName, receiver)
+		w.line("if visited[pair] {")
+		w.line("\treturn true")
+		w.line("}")
+		w.line("visited[pair] = true")
+	}
+	a, b := receiver, "other"
+	if mu != nil {
+		a, b = "mine", "theirs"
+		w.line("var %s, %s %s", a, b, stName)
+		g.snapshot(w, st, mu, a, receiver)
+		g.snapshot(w, st, mu, b, "other")
+	}
+	for _, field := range g.valueFields(st) {
+		x, t := g.load(st, a, field)
+		y, _ := g.load(st, b, field)
+		e.compare(w, x, y, t, 0)
+	}
+	w.line("return true")
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// genDiff produces the Diff(other *T) []string method of the struct type,
+// listing the valueFields differing between the receiver and other as
+// Equal compares them, on copies taken under the read lock when the
+// accessors lock. A nil value has the fields of the zero value.
+func (g *Generator) genDiff(st *StructInfo, receiver string) string {
+	w := &codeWriter{}
+	w.line("// Diff returns the names of the fields differing between %s and other,", receiver)
+	w.line("// a nil value having the zero value of every field.")
+	w.line("func (%s *%s) Diff(other *%s) []string {", receiver, st.TypeName(), st.TypeName())
+	w.indent++
+	w.line("if %s == nil {", receiver)
+	w.line("\t%s = &%s{}", receiver, st.TypeName())
+	w.line("}")
+	w.line("if other == nil {")
+	w.line("\tother = &%s{}", st.TypeName())
+	w.line("}")
+	x, y := receiver, "other"
+	if mu := g.structLock(st); mu != nil {
+		x, y = "mine", "theirs"
+		w.line("var %s, %s %s", x, y, st.TypeName())
+		g.snapshot(w, st, mu, x, receiver)
+		g.snapshot(w, st, mu, y, "other")
+	}
+	w.line("var diff []string")
+	for _, field := range g.valueFields(st) {
+		a, t := g.load(st, x, field)
+		b, _ := g.load(st, y, field)
+		add := "diff = append(diff, " + strconv.Quote(field.Name) + ")"
+		e := &equaler{g: g, stName: st.Name}
+		scratch := &codeWriter{}
+		e.compare(scratch, a, b, t, 0)
+		if len(e.conds) == 1 && strings.Count(scratch.String(), "\n") == 3 {
+			// A single condition needs no function.
+			w.line("if %s {", e.conds[0])
+			w.line("\t%s", add)
+			w.line("}")
+			continue
+		}
+		w.line("if !func() bool {")
+		w.indent++
+		e.compare(w, a, b, t, 0)
+		w.line("return true")
+		w.indent--
+		w.line("}() {")
+		w.line("\t%s", add)
+		w.line("}")
+	}
+	w.line("return diff")
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// snapshot writes the statements copying the valueFields of x into the
+// variable v under the read lock mu. Atomic fields are loaded and stored.
+func (g *Generator) snapshot(w *codeWriter, st *StructInfo, mu *lock, v, x string) {
+	lock, unlock := mu.rlock(x)
+	w.line("%s", lock)
+	for _, field := range g.valueFields(st) {
+		value, _ := g.load(st, x, field)
+		name, ok := atomicType(field.typ)
+		switch {
+		case ok && name == "Value":
+			// Storing nil panics.
+			w.line("if %s != nil {", value)
+			w.line("\t%s.%s.Store(%s)", v, field.Name, value)
+			w.line("}")
+		case ok:
+			w.line("%s.%s.Store(%s)", v, field.Name, value)
+		default:
+			w.line("%s.%s = %s", v, field.Name, value)
+		}
+	}
+	w.line("%s", unlock)
+}
+
+// equaler holds the state of generating one Equal method.
+type equaler struct {
+	g      *Generator
+	stName string
+	conds  []string // conditions written by differ
+	// self is the type compared when it is self-referential; its values
+	// are compared through equal.
+	self *types.Named
+}
+
+// compare writes the statements returning false when the values a and b
+// of type t differ. The expressions are addressable.
+func (e *equaler) compare(w *codeWriter, a, b string, t types.Type, depth int) {
+	if e.isSelf(t) {
+		e.differ(w, fmt.Sprintf("!%s.equal(&%s, visited)", paren(a), b))
+		return
+	}
+	if ptr, ok := e.equalMethod(t); ok {
+		if ptr {
+			b = "&" + b
+		}
+		e.differ(w, fmt.Sprintf("!%s.Equal(%s)", paren(a), b))
+		return
+	}
+	if p, ok := t.(*types.TypeParam); ok {
+		if types.Comparable(p) {
+			e.differ(w, a+" != "+b)
+		} else {
+			e.deepEqual(w, a, b)
+		}
+		return
+	}
+	suffix := ""
+	if depth > 0 {
+		suffix = fmt.Sprint(depth)
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		if e.isSelf(u.Elem()) {
+			e.differ(w, fmt.Sprintf("!%s.equal(%s, visited)", paren(a), b))
+			return
+		}
+		if ptr, ok := e.equalMethod(u.Elem()); ok && ptr {
+			// The Equal method of a generated type handles nil.
+			e.differ(w, fmt.Sprintf("!%s.Equal(%s)", paren(a), b))
+			return
+		}
+		e.differ(w, fmt.Sprintf("(%s == nil) != (%s == nil)", a, b))
+		w.line("if %s != nil {", a)
+		w.indent++
+		e.compare(w, "*"+paren(a), "*"+paren(b), u.Elem(), depth+1)
+		w.indent--
+		w.line("}")
+	case *types.Slice:
+		e.differ(w, fmt.Sprintf("len(%s) != len(%s)", a, b))
+		e.elements(w, a, b, u.Elem(), depth, suffix)
+	case *types.Array:
+		if types.Comparable(t) {
+			e.differ(w, a+" != "+b)
+			return
+		}
+		e.elements(w, a, b, u.Elem(), depth, suffix)
+	case *types.Map:
+		k, va, vb := "k"+suffix, "va"+suffix, "vb"+suffix
+		e.differ(w, fmt.Sprintf("len(%s) != len(%s)", a, b))
+		w.line("for %s, %s := range %s {", k, va, a)
+		w.indent++
+		w.line("%s, ok := %s[%s]", vb, paren(b), k)
+		e.differ(w, "!ok")
+		e.compare(w, va, vb, u.Elem(), depth+1)
+		w.indent--
+		w.line("}")
+	case *types.Interface:
+		e.deepEqual(w, a, b)
+	default:
+		if types.Comparable(t) {
+			e.differ(w, a+" != "+b)
+		} else {
+			e.deepEqual(w, a, b)
+		}
+	}
+}
+
+// isSelf reports whether t is the self-referential type being compared.
+func (e *equaler) isSelf(t types.Type) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && e.self != nil && n.Origin() == e.self
+}
+
+// elements writes the loop comparing the elements of the slices or arrays
+// a and b, of the same length.
+func (e *equaler) elements(w *codeWriter, a, b string, elem types.Type, depth int, suffix string) {
+	i := "i" + suffix
+	w.line("for %s := range %s {", i, a)
+	w.indent++
+	e.compare(w, paren(a)+"["+i+"]", paren(b)+"["+i+"]", elem, depth+1)
+	w.indent--
+	w.line("}")
+}
+
+// differ writes the statement returning false when cond holds.
+func (e *equaler) differ(w *codeWriter, cond string) {
+	e.conds = append(e.conds, cond)
+	w.line("if %s {", cond)
+	w.line("\treturn false")
+	w.line("}")
+}
+
+// deepEqual writes the comparison of a and b by reflect.DeepEqual.
+func (e *equaler) deepEqual(w *codeWriter, a, b string) {
+	e.g.addImport(e.stName, Import{Path: "reflect"})
+	e.differ(w, fmt.Sprintf("!reflect.DeepEqual(%s, %s)", a, b))
+}
+
+// equalMethod reports whether values of type t are compared by an Equal
+// method, and whether it takes a pointer: the types of the package whose
+// Equal method is generated, and the types with an Equal(T) bool or
+// Equal(*T) bool method, such as time.Time, declared outside the files
+// generated in the package.
+func (e *equaler) equalMethod(t types.Type) (ptr, ok bool) {
+	if named, isNamed := types.Unalias(t).(*types.Named); isNamed && named.Obj().Pkg() == e.g.pkg.types {
+		if e.g.equal[named.Obj().Name()] {
+			return true, true
+		}
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, e.g.pkg.types, "Equal")
+	fn, isFunc := obj.(*types.Func)
+	if !isFunc || fn.Pkg() == e.g.pkg.types && e.g.generatedFile(fn.Pos()) != "" {
+		return false, false
+	}
+	sig := fn.Signature()
+	if sig.Params().Len() != 1 || sig.Variadic() || sig.Results().Len() != 1 || !isBool(sig.Results().At(0).Type()) {
+		return false, false
+	}
+	switch param := sig.Params().At(0).Type(); {
+	case types.Identical(param, t):
+		return false, true
+	case types.Identical(param, types.NewPointer(t)):
+		return true, true
+	}
+	return false, false
+}
+
+// paren parenthesizes the expression x when it is a dereference, for it to
+// be indexed or selected from.
+func paren(x string) string {
+	if strings.HasPrefix(x, "*") || strings.HasPrefix(x, "&") {
+		return "(" + x + ")"
+	}
+	return x
+}
@@ -0,0 +1,132 @@
+package gen
+
+import "testing"
+
+func TestSelfReferential(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Node struct {
+	Name     string
+	Next     *Node
+	Children []Node
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Equal = true
+	cfg.Diff = true
+	cfg.Clone = true
+	src := generate(t, cfg, dir, "Node")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestSelfReferential(t *testing.T) {
+	a := &Node{Name: "a", Children: []Node{{Name: "c"}}}
+	a.Next = a
+	b := &Node{Name: "a", Children: []Node{{Name: "c"}}}
+	b.Next = b
+	if !a.Equal(b) {
+		t.Error("equal cycles are not Equal")
+	}
+	if diff := a.Diff(b); len(diff) != 0 {
+		t.Errorf("Diff = %v, want none", diff)
+	}
+	b.Children[0].Name = "d"
+	if a.Equal(b) {
+		t.Error("differing cycles are Equal")
+	}
+	// Next points to the differing node itself.
+	if diff := a.Diff(b); len(diff) != 2 || diff[0] != "Next" || diff[1] != "Children" {
+		t.Errorf("Diff = %v, want [Next Children]", diff)
+	}
+
+	c := a.Clone()
+	if c == a || c.Next != c {
+		t.Error("the clone does not keep the cycle to itself")
+	}
+	if !c.Equal(a) {
+		t.Error("the clone is not Equal to the original")
+	}
+	c.Children[0].Name = "e"
+	if a.Children[0].Name != "c" {
+		t.Error("the clone shares Children with the original")
+	}
+}
+`)
+}
+
+func TestEqualAtomicFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": atomicSrc + `
+type Node struct {
+	mu   sync.Mutex
+	Name string
+	Next *Node
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Equal = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter", "Node")
+	contains(t, src, "mine.hits.Load() != theirs.hits.Load()")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	a, b := &Counter{Name: "a"}, &Counter{Name: "a"}
+	a.SetHits(2)
+	b.SetHits(2)
+	if !a.Equal(b) || !a.Equal(a) {
+		t.Error("equal counters are not Equal")
+	}
+	b.SetHits(3)
+	if a.Equal(b) {
+		t.Error("hits are not compared")
+	}
+	b.SetHits(2)
+	b.SetTotal(1)
+	if a.Equal(b) {
+		t.Error("total is not compared")
+	}
+}
+
+func TestEqualCycle(t *testing.T) {
+	a := &Node{Name: "a"}
+	a.Next = a
+	b := &Node{Name: "a"}
+	b.Next = &Node{Name: "a"}
+	b.Next.Next = b.Next
+	if !a.Equal(b) {
+		t.Error("equal cycles are not Equal")
+	}
+}
+`)
+}
+
+func TestDiffAtomicFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": atomicSrc})
+	cfg := DefaultConfig()
+	cfg.Diff = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter")
+	contains(t, src, "mine.hits.Load() != theirs.hits.Load()")
+	runTests(t, dir, src, `package p
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a, b := &Counter{Name: "a"}, &Counter{Name: "a"}
+	a.SetHits(2)
+	b.SetTotal(1)
+	if got, want := a.Diff(b), []string{"hits", "total"}; !slices.Equal(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+	if got := a.Diff(a); got != nil {
+		t.Errorf("Diff() = %v, want nil", got)
+	}
+}
+`)
+}
@@ -0,0 +1,59 @@
+package gen
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error kinds reported by the generator. They are matched with errors.Is
+// against the *Error values returned by Generate and ParseStruct.
+var (
+	ErrTypeNotFound    = errors.New("type not found")
+	ErrNotStruct       = errors.New("not a struct type")
+	ErrParse           = errors.New("parse error")
+	ErrMethodCollision = errors.New("method collision")
+	ErrUnsupported     = errors.New("unsupported field type")
+	ErrConflict        = errors.New("conflicting options")
+	ErrInvalidOption   = errors.New("invalid tag option")
+	ErrMissingField    = errors.New("missing field")
+	ErrAmbiguousField  = errors.New("ambiguous field")
+	ErrHookSignature   = errors.New("hook with a wrong signature")
+	ErrForeignType     = errors.New("type of another package")
+	ErrFormat          = errors.New("generated code does not format")
+	ErrIO              = errors.New("i/o error")
+	ErrTemplate        = errors.New("template error")
+	ErrNotGenerated    = errors.New("output file is not generated code")
+)
+
+// Error is a generator failure together with the type, and possibly the
+// field, it concerns.
+type Error struct {
+	Kind  error  // one of the Err* values
+	Type  string // type name, if known
+	Field string // field name, if known
+	Err   error  // underlying cause, may be nil
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Type != "" {
+		b.WriteString(e.Type)
+		if e.Field != "" {
+			b.WriteString(".")
+			b.WriteString(e.Field)
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Kind.Error())
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is reports whether target is the kind of e.
+func (e *Error) Is(target error) bool { return target == e.Kind }
@@ -0,0 +1,80 @@
+package gen
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tag := func(s string) string { return "`access:\"" + s + "\"`" }
+	tests := []struct {
+		name     string
+		src      string
+		typeName string
+		config   func(*Config)
+		kind     error
+		field    string
+	}{
+		{name: "type not found", src: "type T struct{ A int }", typeName: "Missing", kind: ErrTypeNotFound},
+		{name: "not a struct", src: "type T int", typeName: "T", kind: ErrNotStruct},
+		{name: "parse", src: "type T struct{ A int `access:\"r`\n}", typeName: "T", kind: ErrParse, field: "A"},
+		{name: "collision", src: "type T struct{ A int }\n\nfunc (t *T) GetA() int { return 0 }", typeName: "T", kind: ErrMethodCollision, field: "A"},
+		{name: "unsupported", src: "type T struct{ A int " + tag("r,w,slice") + " }", typeName: "T", kind: ErrUnsupported, field: "A"},
+		{name: "conflict", src: "type T struct{ A int64 " + tag("r,w,atomic") + " }", typeName: "T",
+			config: func(cfg *Config) { cfg.Immutable = true }, kind: ErrConflict, field: "A"},
+		{name: "atomic changes", src: "type T struct {\n\tA int64 " + tag("r,w,atomic") + "\n\tc map[string]bool " + tag("changes") + "\n}", typeName: "T",
+			config: func(cfg *Config) { cfg.TrackChanges = true }, kind: ErrConflict, field: "A"},
+		{name: "atomic observers", src: "type T struct {\n\tA int64 " + tag("r,w,atomic") + "\n\to map[string][]interface{} " + tag("observers") + "\n}", typeName: "T",
+			config: func(cfg *Config) { cfg.Observers = true }, kind: ErrConflict, field: "A"},
+		{name: "invalid option", src: "type T struct{ A int " + tag("r,w,min=one") + " }", typeName: "T", kind: ErrInvalidOption, field: "A"},
+		{name: "missing field", src: "type T struct{ A int }", typeName: "T",
+			config: func(cfg *Config) { cfg.ThreadSafe = true }, kind: ErrMissingField},
+		{name: "ambiguous field", src: "import \"sync\"\n\ntype T struct {\n\tA int\n\tmu, mu2 sync.Mutex\n}", typeName: "T",
+			config: func(cfg *Config) { cfg.ThreadSafe = true }, kind: ErrAmbiguousField},
+		{name: "safe lock", src: "import \"sync/atomic\"\n\ntype T struct {\n\tA int\n\tn atomic.Int64\n}", typeName: "T",
+			config: func(cfg *Config) { cfg.Safe = true }, kind: ErrUnsupported, field: "n"},
+		{name: "hook signature", src: "type T struct{ A int }\n\nfunc (t *T) beforeSetA(old string) {}", typeName: "T", kind: ErrHookSignature, field: "A"},
+		{name: "foreign type", src: "import \"strings\"\n\ntype T = strings.Builder", typeName: "T", kind: ErrForeignType},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := testPackage(t, map[string]string{"p.go": "package p\n\n" + test.src + "\n"})
+			cfg := DefaultConfig()
+			if test.config != nil {
+				test.config(&cfg)
+			}
+			err := generateErr(t, cfg, dir, test.typeName)
+			if !errors.Is(err, test.kind) {
+				t.Fatalf("Generate(%s) = %v, want %v", test.typeName, err, test.kind)
+			}
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("Generate(%s) = %T, want *Error", test.typeName, err)
+			}
+			if e.Type != test.typeName || e.Field != test.field {
+				t.Errorf("error concerns %s.%s, want %s.%s", e.Type, e.Field, test.typeName, test.field)
+			}
+		})
+	}
+}
+
+func TestParseStructError(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", "package p\n\ntype Bad struct{ A int `access:\"r`\n}\n\ntype Good struct{ B int }\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, err := ParseStruct(file, fset, AccessTagName)
+	if !errors.Is(err, ErrParse) {
+		t.Fatalf("ParseStruct error = %v, want %v", err, ErrParse)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Type != "Bad" || e.Field != "A" {
+		t.Errorf("ParseStruct error = %#v, want one concerning Bad.A", e)
+	}
+	if structs["Good"] == nil || structs["Bad"] != nil {
+		t.Errorf("ParseStruct returned %v, want Good only", structs)
+	}
+}
@@ -0,0 +1,70 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// toFunc rewrites the methods of the struct type in src, rendered by an
+// accessor template, into package-level functions taking the receiver as
+// their first parameter, for -funcs: func (u *User) GetUserName() string
+// becomes func GetUserName(u *User) string. The functions of a generic
+// type get its type parameters. The methods named in rename are renamed.
+func (g *Generator) toFunc(st *StructInfo, src string, rename map[string]string) (string, error) {
+	fset := token.NewFileSet()
+	const header = "package p\n"
+	file, err := parser.ParseFile(fset, "", header+src, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	text := header + src
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var b strings.Builder
+	last := 0
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+			continue
+		}
+		name := fn.Name.Name
+		if to, ok := rename[name]; ok {
+			name = to
+		}
+		if err := g.declareFunc(st.Name, name); err != nil {
+			return "", err
+		}
+		recv := text[offset(fn.Recv.Opening)+1 : offset(fn.Recv.Closing)]
+		params := text[offset(fn.Type.Params.Opening)+1 : offset(fn.Type.Params.Closing)]
+		if strings.TrimSpace(params) != "" {
+			recv += ", "
+		}
+		b.WriteString(text[last:offset(fn.Recv.Opening)])
+		fmt.Fprintf(&b, "%s%s(%s%s)", name, st.TypeParamsDecl(), recv, params)
+		last = offset(fn.Type.Params.Closing) + 1
+	}
+	b.WriteString(text[last:])
+	return strings.TrimPrefix(b.String(), header), nil
+}
+
+// funcName returns the name the accessors of the field named name take:
+// with -funcs, the functions name the type too, as in GetUserName.
+func (g *Generator) funcName(stName, name string) string {
+	if g.funcs {
+		return stName + name
+	}
+	return name
+}
+
+// declareFunc reports an ErrMethodCollision if the function name is
+// already declared in the package outside the generated files.
+func (g *Generator) declareFunc(stName, name string) error {
+	obj := g.pkg.types.Scope().Lookup(name)
+	if obj == nil || g.generatedFile(obj.Pos()) != "" {
+		return nil
+	}
+	return &Error{Kind: ErrMethodCollision, Type: stName,
+		Err: fmt.Errorf("function %s is already declared at %s", name, g.pkg.files[0].fileSet.Position(obj.Pos()))}
+}
@@ -0,0 +1,48 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFuncs(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	name  string            ` + "`access:\"r,w\"`" + `
+	tags  []string          ` + "`access:\"r,w,slice\"`" + `
+	attrs map[string]string ` + "`access:\"r,w,map\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Funcs = true
+	src := generate(t, cfg, dir, "User")
+	if strings.Contains(src, "func (u *User)") {
+		t.Errorf("-funcs generated methods:\n%s", src)
+	}
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestFuncs(t *testing.T) {
+	u := &User{}
+	SetUserName(u, "a")
+	if got := GetUserName(u); got != "a" {
+		t.Errorf("GetUserName() = %q, want a", got)
+	}
+	AppendUserTags(u, "x", "y", "z")
+	RemoveUserTagsAt(u, 1)
+	if n := UserTagsLen(u); n != 2 || UserTagsAt(u, 1) != "z" {
+		t.Errorf("tags = %v", GetUserTags(u))
+	}
+	StoreUserAttrs(u, "k", "v")
+	if v, ok := LookupUserAttrs(u, "k"); !ok || v != "v" {
+		t.Errorf("LookupUserAttrs(k) = %q, %v", v, ok)
+	}
+	DeleteUserAttrs(u, "k")
+	if _, ok := LookupUserAttrs(u, "k"); ok {
+		t.Error("DeleteUserAttrs left k")
+	}
+}
+`)
+}
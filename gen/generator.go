@@ -0,0 +1,2180 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/fatih/structtag"
+	"go/ast"
+	"go/build/constraint"
+	"go/format"
+	"io"
+
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+const AccessRead = "r"
+const AccessWrite = "w"
+const AccessTagName = "access"
+
+// AccessSkip excludes a field from generation.
+const AccessSkip = "-"
+
+// AccessSkipZero makes the setter ignore zero value inputs.
+const AccessSkipZero = "skipzero"
+
+// AccessChain makes the setter return the receiver for call chaining.
+const AccessChain = "chain"
+
+// AccessErrSet makes the setter return an error, reporting the broken
+// constraints of the field instead of panicking.
+const AccessErrSet = "errset"
+
+// AccessUnexported makes the getter and setter names unexported, as in
+// getName and setName, for access controlled within the package.
+const AccessUnexported = "unexported"
+
+// AccessImmutable makes the write access generate a With<Field> method
+// returning a modified copy instead of a setter.
+const AccessImmutable = "immutable"
+
+// AccessSync makes the accessors lock the mutex of the struct.
+const AccessSync = "sync"
+
+// AccessMutex marks the mutex field thread-safe accessors lock.
+const AccessMutex = "mutex"
+
+// AccessAtomic makes the accessors load and store the field atomically.
+const AccessAtomic = "atomic"
+
+// AccessNilSafe makes the getter return the zero value on a nil receiver.
+const AccessNilSafe = "nilsafe"
+
+// AccessCopy makes the getter of a slice or map field return a copy.
+const AccessCopy = "copy"
+
+// AccessSlice adds Append, Remove, At and Len helpers for a slice field.
+const AccessSlice = "slice"
+
+// AccessMap adds Lookup, Store and Delete helpers for a map field.
+const AccessMap = "map"
+
+// AccessName is the key of the option overriding the name used in the
+// method names, as in access:"r,w,name=ID".
+const AccessName = "name"
+
+// AccessIs and AccessHas name the getter of a bool field Is<Field> or
+// Has<Field> instead of using the getter prefix.
+const (
+	AccessIs  = "is"
+	AccessHas = "has"
+)
+
+// Receiver kinds of the getters, chosen by -receiver-kind or the
+// //accessor:receiver= directive of a type.
+const (
+	ReceiverPointer = "pointer"
+	ReceiverValue   = "value"
+)
+
+const receiverDirective = "//accessor:receiver="
+
+// Policies for a generated method whose name is taken by a hand-written
+// method of the type, chosen by -on-conflict.
+const (
+	ConflictError     = "error"     // report an ErrMethodCollision
+	ConflictSkip      = "skip"      // leave the generated method out
+	ConflictOverwrite = "overwrite" // generate it anyway, for the hand-written one to be removed
+)
+
+// errSkipMethod is returned by methodSet.declare for a method left out
+// with ConflictSkip.
+var errSkipMethod = errors.New("method skipped")
+
+// AccessRequired marks a field that must be set before a value is built.
+const AccessRequired = "required"
+
+// Config selects what a Generator produces. DefaultConfig returns the
+// defaults of the accessor command.
+type Config struct {
+	Tag          string   // struct tag holding the access modes
+	Embedded     bool     // also generate accessors for fields promoted from embedded structs
+	DeepCopy     bool     // also generate DeepCopyInto and DeepCopy
+	Clone        bool     // also generate Clone, returning the DeepCopy
+	Equal        bool     // also generate Equal, otherwise only for the types with an //accessor:equal directive
+	Stringer     bool     // also generate String, hiding the secret fields
+	GoStringer   bool     // also generate GoString, printing Go syntax for %#v
+	Slog         bool     // also generate LogValue, making the types slog.LogValuers
+	Zap          bool     // also generate MarshalLogObject, making the types zapcore.ObjectMarshalers
+	Maps         bool     // also generate ToMap and FromMap
+	ByName       bool     // also generate GetField and SetField
+	FieldNames   bool     // also generate the <Type>Field<Name> constants
+	Fields       bool     // also generate Fields, describing the fields with accessors
+	IsZero       bool     // also generate IsZero
+	Reset        bool     // also generate Reset
+	Merge        bool     // also generate Merge
+	Diff         bool     // also generate Diff
+	Patch        bool     // also generate a <Type>Patch and Apply
+	Constructor  bool     // also generate New<Type> taking the required fields
+	Safe         bool     // also generate a Safe<Type> wrapper locking the accessors
+	Funcs        bool     // generate the accessors as functions taking the value, named after the type
+	Unexported   bool     // getter and setter names are unexported
+	Audit        bool     // setters call auditLog(field string, old, new interface{})
+	Builder      bool     // also generate a <Type>Builder
+	Options      bool     // also generate New<Type>(opts ...<Type>Option)
+	Immutable    bool     // generate With<Field> copies instead of setters
+	ThreadSafe   bool     // accessors lock the mutex of the struct
+	NilSafe      bool     // getters accept a nil receiver
+	OkGetters    bool     // also generate Get<Field>Ok for pointer fields
+	Defensive    bool     // getters copy slices and maps
+	GetterPrefix string   // prefix of the getter names, empty for Go style getters
+	SetterPrefix string   // prefix of the setter names
+	BoolPrefix   string   // getter prefix of bool fields, if different
+	Initialisms  []string // initialisms written in capitals besides the built-in ones
+	LegacyNames  bool     // method names use field names as written
+	Receiver     string   // receiver name, if not chosen from the type name
+	ReceiverKind string   // ReceiverPointer or ReceiverValue, for the getters
+	Interface    bool     // also generate the <Type>Accessor interface
+	Mock         bool     // also generate Mock<Type>Accessor
+	View         bool     // also generate the read-only <Type>View
+	WithTests    bool     // also generate round trip tests
+	Chain        bool     // setters return the receiver
+	ErrSetters   bool     // setters return an error
+	TrackChanges bool     // setters record the fields set, read by ChangedFields
+	Observers    bool     // also generate On<Field>Change, registering functions the setters call
+	SortFields   bool     // emit accessors ordered by field name
+	ColumnTag    string   // struct tag read by the <Field>Column methods; empty for none
+	TemplateDir  string   // directory of templates replacing the built-in ones
+	Strict       bool     // fail on the first struct type that can't be parsed
+	OnConflict   string   // ConflictError, ConflictSkip or ConflictOverwrite; empty means ConflictError
+	Validate     string   // ValidatePanic, ValidateClamp or ValidateError; empty means ValidatePanic
+	IncludeTests bool     // also load the _test.go files and generate for their types
+	BuildTags    []string // build tags the packages are loaded with
+	GOOS         string   // target operating system of the loaded files, if not the default
+	GOARCH       string   // target architecture of the loaded files, if not the default
+
+	// Cache is the directory caching the packages generated, which Load
+	// skips while they are unchanged; empty for none. CacheKey tells apart
+	// the runs whose output differs by more than the configuration, e.g.
+	// by the output file names.
+	Cache    string
+	CacheKey string
+
+	// Check makes Write compare with the files instead of writing them,
+	// DryRun print the diff of the changes to Stdout.
+	Check  bool
+	DryRun bool
+	// Force makes Write overwrite files that are not marked as generated
+	// code, which it otherwise refuses with an ErrNotGenerated.
+	Force bool
+	// Command is the command recorded in the header of the output.
+	Command string
+	// Version is the version of the generator recorded in the header of
+	// the output; empty for none.
+	Version string
+	// BuildConstraint is written as a //go:build line in the output, e.g.
+	// "linux && !race"; empty for none.
+	BuildConstraint string
+	// Header is written above the generated-code comment of the output,
+	// e.g. a license. Its lines are made comments unless they are already.
+	Header string
+	// Stdout receives the output written to "-" and the diffs of DryRun;
+	// nil means os.Stdout.
+	Stdout io.Writer
+}
+
+// DefaultConfig returns the configuration of the accessor command without
+// flags.
+func DefaultConfig() Config {
+	return Config{
+		Tag:          AccessTagName,
+		GetterPrefix: "Get",
+		SetterPrefix: "Set",
+		ReceiverKind: ReceiverPointer,
+		OnConflict:   ConflictError,
+		Validate:     ValidatePanic,
+		Command:      "accessor",
+	}
+}
+
+// New returns a Generator configured by cfg.
+func New(cfg Config) (*Generator, error) {
+	if cfg.ReceiverKind != ReceiverPointer && cfg.ReceiverKind != ReceiverValue {
+		return nil, fmt.Errorf("receiver kind %q: want %s or %s", cfg.ReceiverKind, ReceiverValue, ReceiverPointer)
+	}
+	switch cfg.OnConflict {
+	case "":
+		cfg.OnConflict = ConflictError
+	case ConflictError, ConflictSkip, ConflictOverwrite:
+	default:
+		return nil, fmt.Errorf("on conflict %q: want %s, %s or %s", cfg.OnConflict, ConflictError, ConflictSkip, ConflictOverwrite)
+	}
+	switch cfg.Validate {
+	case "":
+		cfg.Validate = ValidatePanic
+	case ValidatePanic, ValidateClamp, ValidateError:
+	default:
+		return nil, fmt.Errorf("validate %q: want %s, %s or %s", cfg.Validate, ValidatePanic, ValidateClamp, ValidateError)
+	}
+	if cfg.ErrSetters && (cfg.Chain || cfg.Immutable) {
+		return nil, fmt.Errorf("setters returning errors can't be chained or immutable")
+	}
+	if cfg.Funcs {
+		for _, opt := range []struct {
+			set  bool
+			name string
+		}{
+			{cfg.Maps, "ToMap and FromMap"}, {cfg.ByName, "GetField and SetField"}, {cfg.Merge, "Merge"},
+			{cfg.Patch, "Apply"}, {cfg.Constructor, "the constructor"}, {cfg.Builder, "the builder"},
+			{cfg.Options, "the options"}, {cfg.Safe, "Safe<Type>"},
+			{cfg.Interface, "the interface"}, {cfg.Mock, "the mock"}, {cfg.View, "the view"},
+			{cfg.WithTests, "the tests"}, {cfg.GoStringer, "GoString"}, {cfg.Slog, "LogValue"}, {cfg.Zap, "MarshalLogObject"},
+		} {
+			if opt.set {
+				return nil, fmt.Errorf("%s can't call accessors generated as functions", opt.name)
+			}
+		}
+	}
+	if cfg.Constructor && cfg.Options {
+		return nil, fmt.Errorf("the constructor and the functional options both declare New<Type>")
+	}
+	if cfg.BuildConstraint != "" {
+		if _, err := constraint.Parse("//go:build " + cfg.BuildConstraint); err != nil {
+			return nil, fmt.Errorf("build constraint %q: %s", cfg.BuildConstraint, err)
+		}
+	}
+	if cfg.Receiver != "" && !token.IsIdentifier(cfg.Receiver) {
+		return nil, fmt.Errorf("receiver %q is not a valid identifier", cfg.Receiver)
+	}
+	g := &Generator{
+		buf: make(map[string]*bytes.Buffer),
+		//structInfo: make(map[string]StructFieldInfoArr), //一定不能初始化
+		walkMark:     make(map[string]bool),
+		tagName:      cfg.Tag,
+		embedded:     cfg.Embedded,
+		deepCopyAll:  cfg.DeepCopy,
+		clone:        cfg.Clone,
+		equalAll:     cfg.Equal,
+		stringer:     cfg.Stringer,
+		goStringer:   cfg.GoStringer,
+		slog:         cfg.Slog,
+		zap:          cfg.Zap,
+		maps:         cfg.Maps,
+		byName:       cfg.ByName,
+		fieldNames:   cfg.FieldNames,
+		fieldInfo:    cfg.Fields,
+		isZero:       cfg.IsZero,
+		reset:        cfg.Reset,
+		merge:        cfg.Merge,
+		diff:         cfg.Diff,
+		patch:        cfg.Patch,
+		constructor:  cfg.Constructor,
+		safe:         cfg.Safe,
+		funcs:        cfg.Funcs,
+		unexported:   cfg.Unexported,
+		columnTag:    cfg.ColumnTag,
+		sortFields:   cfg.SortFields,
+		audit:        cfg.Audit,
+		chain:        cfg.Chain,
+		errSetters:   cfg.ErrSetters,
+		trackChanges: cfg.TrackChanges,
+		observers:    cfg.Observers,
+		builder:      cfg.Builder,
+		options:      cfg.Options,
+		immutable:    cfg.Immutable,
+		threadSafe:   cfg.ThreadSafe,
+		nilSafe:      cfg.NilSafe,
+		okGetters:    cfg.OkGetters,
+		defensive:    cfg.Defensive,
+		getterPrefix: cfg.GetterPrefix,
+		setterPrefix: cfg.SetterPrefix,
+		boolPrefix:   cfg.BoolPrefix,
+		legacyNames:  cfg.LegacyNames,
+		receiver:     cfg.Receiver,
+		receiverKind: cfg.ReceiverKind,
+		iface:        cfg.Interface,
+		mock:         cfg.Mock,
+		view:         cfg.View,
+		withTests:    cfg.WithTests,
+		check:        cfg.Check,
+		dryRun:       cfg.DryRun,
+		force:        cfg.Force,
+		strict:       cfg.Strict,
+		onConflict:   cfg.OnConflict,
+		validate:     cfg.Validate,
+		loadTags:     cfg.BuildTags,
+		includeTests: cfg.IncludeTests,
+		goos:         cfg.GOOS,
+		goarch:       cfg.GOARCH,
+		command:      cfg.Command,
+		buildTags:    cfg.BuildConstraint,
+		header:       commentHeader(cfg.Header),
+		version:      cfg.Version,
+		stdout:       cfg.Stdout,
+		out:          new(outputs),
+		cacheDir:     cfg.Cache,
+	}
+	if g.cacheDir != "" {
+		g.cacheSalt = cacheSalt(cfg)
+	}
+	if g.stdout == nil {
+		g.stdout = os.Stdout
+	}
+	g.initialisms = make(map[string]string)
+	for _, s := range commonInitialisms {
+		g.initialisms[s] = s
+	}
+	for _, s := range cfg.Initialisms {
+		if s = strings.TrimSpace(s); s != "" {
+			g.initialisms[strings.ToUpper(s)] = s
+		}
+	}
+	var err error
+	if g.templates, err = parseTemplates(cfg.TemplateDir, g.templateFuncs()); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Generator holds the state of the analysis. Primarily used to buffer
+// the output for format.Source.
+type Generator struct {
+	buf        map[string]*bytes.Buffer // Accumulated output.
+	pkgs       []*Package               // Packages loaded from the patterns.
+	pkg        *Package                 // Package we are scanning.
+	structInfo map[string]*StructInfo
+	parseErrs  map[string]error // errors of the struct types that fail to parse
+	syntaxErrs []error          // syntax errors of the loaded packages
+	walkMark   map[string]bool
+	strict     bool      // fail on the first struct type that can't be parsed
+	onConflict string    // policy for methods taken by hand-written ones
+	validate   string    // what setters do with values breaking constraints
+	loadTags   []string  // build tags of the loaded packages
+	goos       string    // GOOS of the loaded packages, if set
+	goarch     string    // GOARCH of the loaded packages, if set
+	command    string    // command recorded in the header
+	version    string    // generator version recorded in the header, if any
+	buildTags  string    // build constraint of the output, if any
+	header     string    // comment written above the generated-code comment, if any
+	stdout     io.Writer // destination of the output written to "-"
+
+	tagName     string // struct tag holding the access modes
+	embedded    bool   // generate accessors for promoted fields
+	deepCopyAll bool   // generate DeepCopy methods
+	clone       bool   // generate Clone methods besides the DeepCopy ones
+	equalAll    bool   // generate Equal methods for all types
+	stringer    bool   // generate String methods
+	goStringer  bool   // generate GoString methods
+	slog        bool   // generate LogValue methods
+	zap         bool   // generate MarshalLogObject methods
+	maps        bool   // generate ToMap and FromMap methods
+	byName      bool   // generate GetField and SetField methods
+	fieldNames  bool   // generate field name constants
+	fieldInfo   bool   // generate Fields methods
+	isZero      bool   // generate IsZero methods
+	reset       bool   // generate Reset methods
+	merge       bool   // generate Merge methods
+	diff        bool   // generate Diff methods
+	patch       bool   // generate <Type>Patch types and Apply methods
+	constructor bool   // generate New<Type> constructors
+	safe        bool   // generate Safe<Type> wrappers
+	funcs       bool   // generate the accessors as functions
+	unexported  bool   // unexport the getter and setter names
+	// equal holds the types of the package whose Equal method is
+	// generated, compared with it by the other types.
+	equal        map[string]bool
+	deepCopy     map[string]bool // types DeepCopy methods are generated for
+	generating   map[string]bool // types of the package being generated, set by SetTypes
+	columnTag    string          // tag read by the column name methods, if generated
+	sortFields   bool            // emit accessors ordered by field name
+	audit        bool            // setters report changes to auditLog
+	chain        bool            // setters return the receiver
+	errSetters   bool            // setters return an error
+	trackChanges bool            // setters record the fields set
+	observers    bool            // setters notify the functions registered by On<Field>Change
+	builder      bool            // generate a <Type>Builder
+	immutable    bool            // generate With<Field> copies instead of setters
+	threadSafe   bool            // accessors lock the mutex of the struct
+	nilSafe      bool            // getters accept a nil receiver
+	okGetters    bool            // generate Get<Field>Ok for pointer fields
+	defensive    bool            // getters copy slices and maps
+	getterPrefix string
+	setterPrefix string
+	boolPrefix   string                        // getter prefix of bool fields, if set
+	initialisms  map[string]string             // initialisms by their capitals
+	legacyNames  bool                          // method names use field names as written
+	receiver     string                        // receiver name, if set
+	receiverKind string                        // receiver kind of the getters
+	iface        bool                          // generate the <Type>Accessor interface
+	mock         bool                          // generate Mock<Type>Accessor
+	view         bool                          // generate the read-only <Type>View
+	withTests    bool                          // generate round trip tests
+	includeTests bool                          // load the test files too
+	check        bool                          // compare with the files instead of writing
+	out          *outputs                      // files seen by Write
+	dryRun       bool                          // print diffs instead of writing
+	force        bool                          // overwrite files that are not generated
+	options      bool                          // generate a functional options constructor
+	templates    map[string]*template.Template // accessor templates by file name
+	knownTypes   map[string]types.Type         // types of the accessors by their source form
+	rendering    string                        // type whose accessor template is executing
+	cacheDir     string                        // directory of the cache, if any
+	cacheSalt    string                        // part of the cache keys given by the configuration
+	cacheKeys    map[string]string             // cache keys of the packages by import path
+
+	imports map[string]map[string]string // type -> import path -> name
+	plans   map[string]Plan              // type -> what was generated for it
+}
+
+// generatedHeader starts the header of the files written by the
+// accessor command, followed by its arguments or the closing quote.
+const generatedHeader = "// Code generated by \"accessor"
+
+// IsGenerated reports whether file was written by the accessor command,
+// judging by its header. Other commands whose name starts with accessor,
+// such as accessorvet, don't count.
+func IsGenerated(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			rest, ok := strings.CutPrefix(c.Text, generatedHeader)
+			if ok && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\"")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commentHeader returns text as a comment to start the output with: the
+// lines of a text that is not a comment already are prefixed with //.
+func commentHeader(text string) string {
+	text = strings.TrimRight(text, " \t\r\n")
+	trimmed := strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(trimmed, "/*") && strings.HasSuffix(trimmed, "*/") {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "//"):
+		case strings.TrimSpace(line) == "":
+			lines[i] = "//"
+		default:
+			lines[i] = "// " + strings.TrimRight(line, " \t\r")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Bytes returns the gofmt-ed output generated for the named types of the
+// current package, with one header and their imports merged.
+func (g *Generator) Bytes(typeNames ...string) ([]byte, error) {
+	var b bytes.Buffer
+	if g.header != "" {
+		fmt.Fprintf(&b, "%s\n\n", g.header)
+	}
+	fmt.Fprintf(&b, "// Code generated by \"%s\"; DO NOT EDIT.\n", g.command)
+	if g.version != "" {
+		fmt.Fprintf(&b, "// accessor version: %s\n", g.version)
+	}
+	fmt.Fprintf(&b, "\n")
+	if g.buildTags != "" {
+		fmt.Fprintf(&b, "//go:build %s\n", g.buildTags)
+		fmt.Fprintf(&b, "\n")
+	}
+	fmt.Fprintf(&b, "package %s\n", g.pkg.name)
+	fmt.Fprintf(&b, "\n")
+	if decl := g.importDecl(typeNames...); decl != "" {
+		fmt.Fprintf(&b, "%s\n", decl)
+	}
+	for _, typeName := range typeNames {
+		if buf, ok := g.buf[typeName]; ok {
+			b.Write(buf.Bytes())
+		}
+	}
+	src := b.Bytes()
+	formatted, err := format.Source(src)
+	if err != nil {
+		// Should never happen, but can arise when developing this code.
+		// The user can compile the output to see the error.
+		names := make([]string, len(typeNames))
+		for i, typeName := range typeNames {
+			names[i] = strings.TrimSuffix(typeName, testKey(""))
+		}
+		return nil, &Error{Kind: ErrFormat, Type: strings.Join(names, ","), Err: fmt.Errorf("%s\n%s", err, snippet(src, err))}
+	}
+	return formatted, nil
+}
+
+// snippet returns the lines of src around the position of the first error
+// in err, numbered, or all of src if err carries no position.
+func snippet(src []byte, err error) string {
+	lines := strings.Split(string(src), "\n")
+	from, to := 0, len(lines)
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		line := list[0].Pos.Line
+		from, to = line-4, line+3
+		if from < 0 {
+			from = 0
+		}
+		if to > len(lines) {
+			to = len(lines)
+		}
+	}
+	var b strings.Builder
+	for i := from; i < to; i++ {
+		fmt.Fprintf(&b, "%5d\t%s\n", i+1, lines[i])
+	}
+	return b.String()
+}
+
+func (g *Generator) Printf(structName, format string, args ...interface{}) {
+	buf, ok := g.buf[structName]
+	if !ok {
+		buf = bytes.NewBufferString("")
+		g.buf[structName] = buf
+	}
+	fmt.Fprintf(buf, format, args...)
+}
+
+// File holds a single parsed file and associated data.
+type File struct {
+	pkg     *Package  // Package to which this file belongs.
+	file    *ast.File // Parsed AST.
+	fileSet *token.FileSet
+	// These fields are reset for each type being generated.
+	typeName string // Name of the constant type.
+
+}
+
+// Package is a loaded package.
+type Package struct {
+	name  string
+	path  string // import path
+	dir   string // directory holding the package's files
+	defs  map[*ast.Ident]types.Object
+	types *types.Package
+	info  *types.Info
+	files []*File
+	// cached is the cache entry of a package found up to date, which is
+	// not loaded.
+	cached *cacheEntry
+}
+
+// Load loads and type checks the packages matching the patterns, which
+// are directories, import paths or the files of a single package, and
+// makes the first one, in import path order, the current package.
+func (g *Generator) Load(patterns ...string) error {
+	if g.cacheDir != "" {
+		var err error
+		if patterns, err = g.lookupCache(patterns); err != nil {
+			return err
+		}
+	}
+	if len(patterns) > 0 {
+		pkgs, err := packages.Load(g.loadConfig(packages.LoadSyntax), patterns...)
+		if err != nil {
+			return err
+		}
+		if len(pkgs) == 0 {
+			return errors.New("no packages found")
+		}
+		if g.includeTests {
+			pkgs = testVariants(pkgs)
+		}
+		for _, pkg := range pkgs {
+			if len(pkg.GoFiles) == 0 {
+				continue
+			}
+			g.addPackage(pkg)
+		}
+	}
+	if len(g.pkgs) == 0 {
+		return errors.New("no Go files found")
+	}
+	if g.strict && len(g.syntaxErrs) > 0 {
+		return g.syntaxErrs[0]
+	}
+	// Process the packages in a stable order, whatever order they are
+	// loaded in.
+	sort.Slice(g.pkgs, func(i, j int) bool { return g.pkgs[i].path < g.pkgs[j].path })
+	g.SetPackage(g.pkgs[0])
+	return nil
+}
+
+// loadConfig returns the configuration loading the packages with mode for
+// the build tags and target platform of g.
+func (g *Generator) loadConfig(mode packages.LoadMode) *packages.Config {
+	cfg := &packages.Config{
+		Mode:  mode,
+		Tests: g.includeTests,
+	}
+	if len(g.loadTags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(g.loadTags, ",")}
+	}
+	if g.goos != "" || g.goarch != "" {
+		cfg.Env = os.Environ()
+		if g.goos != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+g.goos)
+		}
+		if g.goarch != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+g.goarch)
+		}
+	}
+	return cfg
+}
+
+// testVariants returns the packages to generate for when the tests are
+// loaded: the test variant of each package, holding its _test.go files
+// too, in place of the package, and the external test packages. The test
+// main packages are left out.
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	hasVariant := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test]") {
+			hasVariant[pkg.PkgPath] = true
+		}
+	}
+	var kept []*packages.Package
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test]") || !hasVariant[pkg.PkgPath] && !strings.HasSuffix(pkg.ID, ".test") {
+			kept = append(kept, pkg)
+		}
+	}
+	return kept
+}
+
+// Packages returns the loaded packages in import path order.
+func (g *Generator) Packages() []*Package {
+	return g.pkgs
+}
+
+// SyntaxErrors returns the syntax errors found by Load. Type errors are
+// not reported, as the packages may call accessors yet to be generated.
+func (g *Generator) SyntaxErrors() []error {
+	return g.syntaxErrs
+}
+
+// addPackage adds a type checked Package and its syntax files to the generator.
+func (g *Generator) addPackage(pkg *packages.Package) {
+	p := &Package{
+		name:  pkg.Name,
+		path:  pkg.PkgPath,
+		dir:   filepath.Dir(pkg.GoFiles[0]),
+		defs:  pkg.TypesInfo.Defs,
+		types: pkg.Types,
+		info:  pkg.TypesInfo,
+		files: make([]*File, len(pkg.Syntax)),
+	}
+	for _, err := range pkg.Errors {
+		if err.Kind == packages.ParseError {
+			g.syntaxErrs = append(g.syntaxErrs, &Error{Kind: ErrParse, Err: err})
+		}
+	}
+
+	for i, file := range pkg.Syntax {
+		p.files[i] = &File{
+			file:    file,
+			pkg:     p,
+			fileSet: pkg.Fset,
+		}
+	}
+	g.pkgs = append(g.pkgs, p)
+}
+
+// Name returns the package name.
+func (p *Package) Name() string { return p.name }
+
+// Path returns the import path of the package.
+func (p *Package) Path() string { return p.path }
+
+// Dir returns the directory holding the package's files.
+func (p *Package) Dir() string { return p.dir }
+
+// SetPackage makes pkg the package being generated for, discarding the
+// state kept for the previous one.
+func (g *Generator) SetPackage(pkg *Package) {
+	g.pkg = pkg
+	g.buf = make(map[string]*bytes.Buffer)
+	g.structInfo = nil
+	g.parseErrs = make(map[string]error)
+	g.knownTypes = make(map[string]types.Type)
+	g.imports = nil
+	g.plans = nil
+}
+
+// loadStructs parses the struct declarations of every file in the package
+// once and caches them in g.structInfo. The files written by the accessor
+// command are skipped: the types they declare, such as builders, are
+// output and not input. The errors of the types that can't be parsed are
+// kept in g.parseErrs, unless Strict makes the first one fail the load.
+func (g *Generator) loadStructs() (map[string]*StructInfo, error) {
+	if g.structInfo != nil {
+		return g.structInfo, nil
+	}
+	structs := make(map[string]*StructInfo)
+	for _, file := range g.pkg.files { //按包来的，读取包下的所有文件
+		if file.file == nil || IsGenerated(file.file) {
+			continue
+		}
+		structInfo, err := ParseStruct(file.file, file.fileSet, g.tagName)
+		if err != nil && g.strict {
+			return nil, err
+		}
+		for stName, info := range structInfo {
+			structs[stName] = info
+		}
+		// Types that fail to parse are reported when they are generated.
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				var e *Error
+				if errors.As(err, &e) {
+					g.parseErrs[e.Type] = err
+				}
+			}
+		}
+	}
+	g.structInfo = structs
+	return g.structInfo, nil
+}
+
+// generatedFile returns the name of the file of the current package
+// holding pos if it was written by the accessor command, and "" otherwise.
+func (g *Generator) generatedFile(pos token.Pos) string {
+	for _, file := range g.pkg.files {
+		if file.file != nil && file.file.FileStart <= pos && pos < file.file.FileEnd && IsGenerated(file.file) {
+			return file.fileSet.File(pos).Name()
+		}
+	}
+	return ""
+}
+
+// InTestFile reports whether the named type of the current package is
+// declared in a _test.go file, so that its output belongs in one too.
+func (g *Generator) InTestFile(typeName string) bool {
+	structs, _ := g.loadStructs()
+	st := structs[typeName]
+	return st != nil && strings.HasSuffix(st.File, "_test.go")
+}
+
+// MissingTypes returns the names in typeNames that are declared as types
+// in none of the packages.
+func (g *Generator) MissingTypes(typeNames []string) ([]string, error) {
+	declared := make(map[string]bool)
+	for _, pkg := range g.pkgs {
+		if pkg.cached != nil {
+			for _, typeName := range typeNames {
+				if slices.Contains(pkg.cached.Types, typeName) {
+					declared[typeName] = true
+				}
+			}
+			continue
+		}
+		g.SetPackage(pkg)
+		structs, err := g.loadStructs()
+		if err != nil {
+			return nil, err
+		}
+		for _, typeName := range typeNames {
+			_, isType := pkg.types.Scope().Lookup(typeName).(*types.TypeName)
+			if structs[typeName] != nil || isType {
+				declared[typeName] = true
+			}
+		}
+	}
+	var missing []string
+	for _, typeName := range typeNames {
+		if !declared[typeName] {
+			missing = append(missing, typeName)
+		}
+	}
+	return missing, nil
+}
+
+// StructNames returns the names of the package's struct types in
+// alphabetical order, including those that fail to parse.
+func (g *Generator) StructNames() ([]string, error) {
+	structs, err := g.loadStructs()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(structs)+len(g.parseErrs))
+	for stName := range structs {
+		names = append(names, stName)
+	}
+	for stName := range g.parseErrs {
+		names = append(names, stName)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SetTypes tells the generator the types of the current package that are
+// going to be generated. With DeepCopy or Clone their DeepCopy methods are
+// called by those of the other types, and so are their Equal methods.
+func (g *Generator) SetTypes(typeNames []string) {
+	structs, _ := g.loadStructs()
+	g.equal = make(map[string]bool)
+	g.generating = make(map[string]bool)
+	for _, typeName := range typeNames {
+		g.generating[typeName] = true
+		if st := structs[typeName]; st != nil && (g.equalAll || st.Equal) {
+			g.equal[typeName] = true
+		}
+	}
+	if !g.deepCopyAll && !g.clone {
+		return
+	}
+	g.deepCopy = make(map[string]bool)
+	for _, typeName := range typeNames {
+		g.deepCopy[typeName] = true
+	}
+}
+
+// Generate produces the accessor methods for the named type of the current
+// package, to be retrieved with Bytes. A type that fails leaves no output.
+func (g *Generator) Generate(typeName string) error {
+	if err := g.generate(typeName); err != nil {
+		g.discard(typeName)
+		return err
+	}
+	return nil
+}
+
+// Generated reports whether Generate produced any output for the type;
+// there is none when every field is excluded.
+func (g *Generator) Generated(typeName string) bool {
+	return g.buf[typeName] != nil
+}
+
+// discard drops the output of a type whose generation failed part way.
+func (g *Generator) discard(typeName string) {
+	for _, key := range []string{typeName, testKey(typeName)} {
+		delete(g.buf, key)
+		delete(g.imports, key)
+	}
+}
+
+func (g *Generator) generate(typeName string) error {
+	structs, err := g.loadStructs()
+	if err != nil {
+		return err
+	}
+	if err := g.parseErrs[typeName]; err != nil {
+		return err
+	}
+	st, ok := structs[typeName]
+	if !ok {
+		if obj, ok := g.pkg.types.Scope().Lookup(typeName).(*types.TypeName); ok {
+			if file := g.generatedFile(obj.Pos()); file != "" {
+				return &Error{Kind: ErrTypeNotFound, Type: typeName,
+					Err: fmt.Errorf("%s is declared in the generated file %s", typeName, filepath.Base(file))}
+			}
+			st, err = g.aliasedStruct(obj, structs)
+			if err != nil {
+				return err
+			}
+			if st == nil {
+				if st, err = g.definedStruct(obj); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if st == nil {
+		if obj, ok := g.pkg.types.Scope().Lookup(typeName).(*types.TypeName); ok {
+			what := "is"
+			if obj.IsAlias() {
+				what = "is an alias of"
+			}
+			return &Error{Kind: ErrNotStruct, Type: typeName,
+				Err: fmt.Errorf("%s %s %s", typeName, what, types.TypeString(obj.Type().Underlying(), nil))}
+		}
+		return &Error{Kind: ErrTypeNotFound, Type: typeName}
+	}
+	stName, info := typeName, st.Fields
+	var mu *lock
+	for _, field := range info {
+		if g.threadSafe || field.HasOption(AccessSync) {
+			if mu, err = g.lockField(st); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	var changes string // field recording the fields set
+	if g.trackChanges {
+		if changes, err = g.changesField(st); err != nil {
+			return err
+		}
+	}
+	var observers string // field holding the functions registered by On<Field>Change
+	if g.observers {
+		if observers, err = g.observersField(st); err != nil {
+			return err
+		}
+	}
+	methods := newMethodSet(st)
+	if g.onConflict != ConflictOverwrite {
+		methods.handWritten, methods.skip = g.handWrittenMethods(stName), g.onConflict == ConflictSkip
+	}
+	var roundTrips []roundTrip
+	var dynamic []dynamicField // fields reached by name
+	var start int              // start of the accessors in the output
+	if buf, ok := g.buf[stName]; ok {
+		start = buf.Len()
+	}
+	for _, field := range g.fields(info) {
+		if field.Via == "" && (mu != nil && field.Name == mu.Field || field.Name == changes || field.Name == observers) {
+			continue
+		}
+		a := g.newAccessor(st, field)
+		if changes != "" && field.HasAccess(AccessWrite) {
+			if a.Immutable {
+				return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+					Err: fmt.Errorf("%s copies can't track changes", AccessImmutable)}
+			}
+			a.Changes = changes
+		}
+		if observers != "" && field.HasAccess(AccessWrite) {
+			if a.Immutable {
+				return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+					Err: fmt.Errorf("%s copies can't notify observers", AccessImmutable)}
+			}
+			a.Observers = observers
+		}
+		if mu != nil && (g.threadSafe || field.HasOption(AccessSync)) {
+			if a.Immutable {
+				return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+					Err: fmt.Errorf("%s copies can't be thread-safe", AccessImmutable)}
+			}
+			a.Lock, a.RLock = mu.Field, mu.RW
+		}
+		if field.HasOption(AccessAtomic) {
+			if err := g.atomicAccessor(stName, &a, field); err != nil {
+				return err
+			}
+			if a.Changes != "" && a.Lock == "" {
+				// The changes map would be written concurrently.
+				return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+					Err: fmt.Errorf("%s setters can't track changes without a lock", AccessAtomic)}
+			}
+			if a.Observers != "" && a.Lock == "" {
+				// The observers map would be read while On<Field>Change writes it.
+				return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+					Err: fmt.Errorf("%s setters can't notify observers without a lock", AccessAtomic)}
+			}
+		}
+		if a.ValueGetter && (a.Lock != "" || a.Load != "") {
+			return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+				Err: fmt.Errorf("getters with a value receiver can't lock or load atomically")}
+		}
+		if g.defensive || field.HasOption(AccessCopy) {
+			switch g.underlying(field).(type) {
+			case *types.Slice:
+				a.Clone = "slice"
+			case *types.Map:
+				a.Clone = "map"
+			}
+			if a.Clone == "" && field.HasOption(AccessCopy) {
+				return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name,
+					Err: fmt.Errorf("%s option needs a slice or map, got %s", AccessCopy, field.Type)}
+			}
+		}
+		checks, err := g.checks(stName, field)
+		if err != nil {
+			return err
+		}
+		if len(checks) > 0 && field.HasAccess(AccessWrite) {
+			a.Checks, a.Validate = checks, g.validate
+			if a.ReturnsError && a.Validate == ValidatePanic {
+				// A setter returning an error reports the broken constraint.
+				a.Validate = ValidateError
+			}
+			if a.Validate == ValidateError {
+				g.addImport(stName, Import{Path: "errors"})
+				a.ReturnsError = true
+			}
+		}
+		if field.HasAccess(AccessWrite) && !a.Immutable {
+			if err := g.hooks(stName, &a, field); err != nil {
+				return err
+			}
+		}
+		if a.Immutable && field.HasAccess(AccessWrite) && (g.unexported || field.HasOption(AccessUnexported)) {
+			return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+				Err: fmt.Errorf("With methods can't be unexported")}
+		}
+		if a.ReturnsError && field.HasAccess(AccessWrite) && (a.Chain || a.Immutable) {
+			return &Error{Kind: ErrConflict, Type: stName, Field: field.Name,
+				Err: fmt.Errorf("setters returning errors can't be chained or immutable")}
+		}
+		if a.SkipZero && !g.comparable(g.fieldType(field)) {
+			return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name,
+				Err: fmt.Errorf("%s option needs a comparable type, got %s", AccessSkipZero, field.Type)}
+		}
+		if len(field.Access) > 0 && !field.HasOption(AccessAtomic) {
+			for _, imp := range field.Imports {
+				g.addImport(stName, imp)
+			}
+		}
+		skipped := false // an accessor is left to a hand-written method
+		for _, access := range field.Access {
+			var method string
+			switch access {
+			case AccessWrite:
+				method = a.Setter
+				if a.Immutable {
+					method = "With" + a.Name
+				}
+			case AccessRead:
+				method = a.Getter
+			}
+			if err := methods.declare(method, field.Name); err == errSkipMethod {
+				skipped = true
+				continue
+			} else if err != nil {
+				return err
+			}
+			switch access {
+			case AccessWrite:
+				tpl := "setter.tmpl"
+				if a.Immutable {
+					tpl = "wither.tmpl"
+				}
+				if err := g.printAccessor(stName, tpl, a); err != nil {
+					return err
+				}
+			case AccessRead:
+				methods.readers[method] = true
+				if err := g.printAccessor(stName, "getter.tmpl", a); err != nil {
+					return err
+				}
+			}
+		}
+		if a.Observers != "" {
+			if err := methods.declare("On"+a.Name+"Change", field.Name); err == nil {
+				g.Printf(stName, "%s\n", bytes.TrimLeft([]byte(genObserver(a)), "\n"))
+			} else if err != errSkipMethod {
+				return err
+			}
+		}
+		if len(field.Access) > 0 {
+			df := dynamicField{accessor: a, Key: field.Name,
+				Read: field.HasAccess(AccessRead), Write: field.HasAccess(AccessWrite)}
+			df.Required = df.Write && field.HasOption(AccessRequired)
+			if value, ok := field.OptionValue(AccessDefault); ok {
+				if df.Default, err = g.defaultValue(stName, field, value); err != nil {
+					return err
+				}
+			}
+			if key, ok := field.TagValue("json"); ok && key != "" && key != "-" {
+				df.JSON = key
+			}
+			if g.merge && df.Read && df.Write {
+				// Atomic getters return the value type, not the field type.
+				if t := g.fieldType(field); t != nil && a.Load == "" {
+					df.NonZero = nonZero(g.zeroCond(stName, "param", t))
+				} else {
+					df.NonZero = nonZero(a.IsZero("param"))
+				}
+			}
+			dynamic = append(dynamic, df)
+		}
+		if g.withTests && field.HasAccess(AccessRead) && field.HasAccess(AccessWrite) && !skipped && len(a.Checks) == 0 {
+			if rt, ok := g.newRoundTrip(st, a, field); ok {
+				roundTrips = append(roundTrips, rt)
+			}
+		}
+		if field.HasOption(AccessSlice) {
+			if err := g.genSliceHelpers(stName, a, field, methods); err != nil {
+				return err
+			}
+		}
+		if field.HasOption(AccessMap) {
+			if err := g.genMapHelpers(stName, a, field, methods); err != nil {
+				return err
+			}
+		}
+		if g.okGetters && field.HasAccess(AccessRead) && a.Load == "" {
+			ptr, ok := g.fieldType(field).(*types.Pointer)
+			if !ok {
+				continue
+			}
+			if err := methods.declare(a.Getter+"Ok", field.Name); err == errSkipMethod {
+				continue
+			} else if err != nil {
+				return err
+			}
+			methods.readers[a.Getter+"Ok"] = true
+			a.Type = g.typeString(stName, ptr.Elem())
+			a.Zero = zeroOf(ptr.Elem(), a.Type)
+			g.knownTypes[a.Type] = ptr.Elem()
+			if err := g.printAccessor(stName, "getter_ok.tmpl", a); err != nil {
+				return err
+			}
+		}
+	}
+	if g.columnTag != "" {
+		// A value receiver would copy the lock of the struct.
+		recv := st.TypeName()
+		if holdsLock(g.pkg.types.Scope().Lookup(stName).Type()) {
+			recv = "*" + recv
+		}
+		for _, field := range g.fields(info) {
+			if field.Skip || field.Name == "_" || field.HasOption(AccessChanges) || field.HasOption(AccessObservers) {
+				continue
+			}
+			if _, ok := g.mutexType(g.fieldType(field)); ok {
+				continue
+			}
+			column, ok := field.TagValue(g.columnTag)
+			if column == "-" {
+				continue
+			}
+			if !ok || column == "" {
+				column = field.Name
+			}
+			// Named like the accessors: IDColumn for id, or after name=.
+			name := g.newAccessor(st, field).Name
+			if err := methods.declare(name+"Column", field.Name); err == errSkipMethod {
+				continue
+			} else if err != nil {
+				return err
+			}
+			g.Printf(stName, "%s\n", genColumn(recv, name, column))
+		}
+	}
+	if changes != "" {
+		ok, err := methods.declareAll("", "ChangedFields", "ClearChanges")
+		if err != nil {
+			return err
+		}
+		if ok {
+			a := accessor{Receiver: g.receiverName(st), Struct: st.TypeName(), Changes: changes}
+			if mu != nil {
+				a.Lock, a.RLock = mu.Field, mu.RW
+			}
+			methods.readers["ChangedFields"] = true
+			g.addImport(stName, Import{Path: "sort"})
+			g.Printf(stName, "%s", genChanges(a))
+		}
+	}
+	if g.fieldNames {
+		g.Printf(stName, "%s\n", g.genDynamic("names", st, dynamic, mu))
+	}
+	if g.fieldInfo {
+		ok, err := methods.declareAll("", "Fields")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "%s\n", g.genDynamic("fields", st, dynamic, mu))
+		}
+	}
+	if g.reset {
+		ok, err := methods.declareAll("", "Reset")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "%s\n", g.genDynamic("reset", st, dynamic, mu))
+		}
+	}
+	if g.merge {
+		ok, err := methods.declareAll("", "Merge")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "%s\n", g.genDynamic("merge", st, dynamic, mu))
+		}
+	}
+	if g.patch {
+		ok, err := methods.declareAll("", "Apply")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "%s\n", g.genDynamic("patch", st, dynamic, mu))
+		}
+	}
+	if g.maps {
+		ok, err := methods.declareAll("", "ToMap", "FromMap")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.addImport(stName, Import{Path: "fmt"})
+			g.Printf(stName, "%s\n", g.genDynamic("maps", st, dynamic, mu))
+		}
+	}
+	if g.byName {
+		ok, err := methods.declareAll("", "GetField", "SetField")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.addImport(stName, Import{Path: "fmt"})
+			g.Printf(stName, "%s\n", g.genDynamic("byname", st, dynamic, mu))
+		}
+	}
+	if g.withTests {
+		g.genTests(st, roundTrips)
+	}
+	var sigs []string // signatures of the methods generated so far
+	if buf, ok := g.buf[stName]; ok {
+		sigs = signatures(buf.String()[start:])
+	}
+	if g.view {
+		ok, err := methods.declareAll("", "View")
+		if err != nil {
+			return err
+		}
+		if ok {
+			view, err := genView(st, g.receiverName(st), sigs, methods.readers)
+			if err != nil {
+				return err
+			}
+			g.Printf(stName, "%s", view)
+			sigs = append(sigs, "View() "+st.Name+"View"+st.TypeArgs())
+		}
+	}
+	if g.iface || g.mock {
+		// The exported interface leaves the unexported accessors out.
+		sigs = slices.DeleteFunc(sigs, func(sig string) bool { return !token.IsExported(sig) })
+		g.Printf(stName, "%s", genInterface(st, sigs))
+		if g.mock {
+			mock, err := genMock(st, sigs)
+			if err != nil {
+				return err
+			}
+			g.Printf(stName, "%s", mock)
+		}
+	}
+	if g.builder {
+		g.Printf(stName, "%s", g.genBuilder(st, dynamic))
+	}
+	if g.options {
+		g.Printf(stName, "%s", g.genOptions(st, dynamic))
+	}
+	if g.constructor {
+		g.Printf(stName, "%s", g.genConstructor(st, dynamic))
+	}
+	if g.safe {
+		safe, err := g.genSafe(st, dynamic)
+		if err != nil {
+			return err
+		}
+		g.Printf(stName, "%s", safe)
+	}
+	if g.stringer {
+		ok, err := methods.declareAll("", "String")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genString(st, g.receiverName(st)))
+		}
+	}
+	if g.goStringer {
+		ok, err := methods.declareAll("", "GoString")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genGoString(st, g.receiverName(st)))
+		}
+	}
+	if g.slog {
+		ok, err := methods.declareAll("", "LogValue")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genLogValue(st, g.receiverName(st)))
+		}
+	}
+	if g.zap {
+		ok, err := methods.declareAll("", "MarshalLogObject")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genMarshalLogObject(st, g.receiverName(st)))
+		}
+	}
+	if g.isZero {
+		ok, err := methods.declareAll("", "IsZero")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genIsZero(st, g.receiverName(st)))
+		}
+	}
+	if g.equal[stName] {
+		ok, err := methods.declareAll("", "Equal")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genEqual(st, g.receiverName(st)))
+		}
+	}
+	if g.diff {
+		ok, err := methods.declareAll("", "Diff")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "\n%s", g.genDiff(st, g.receiverName(st)))
+		}
+	}
+	if g.deepCopy[stName] {
+		ok, err := methods.declareAll("", "DeepCopyInto", "DeepCopy")
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Printf(stName, "%s", g.genDeepCopy(st))
+		}
+		if g.clone {
+			ok, err := methods.declareAll("", "Clone")
+			if err != nil {
+				return err
+			}
+			if ok {
+				g.Printf(stName, "\n%s", genClone(st, g.receiverName(st)))
+			}
+		}
+	}
+	g.recordPlan(stName, st, methods)
+	return nil
+}
+
+// aliasedStruct returns the struct type of the package obj is an alias of,
+// type Account = account, whose methods are those of the alias, and nil
+// if obj is not an alias of a named type. An alias of a type of another
+// package is reported, as methods can't be declared on it.
+func (g *Generator) aliasedStruct(obj *types.TypeName, structs map[string]*StructInfo) (*StructInfo, error) {
+	if !obj.IsAlias() {
+		return nil, nil
+	}
+	named, ok := types.Unalias(obj.Type()).(*types.Named)
+	if !ok {
+		return nil, nil
+	}
+	target := named.Origin().Obj()
+	if target.Pkg() != g.pkg.types {
+		return nil, &Error{Kind: ErrForeignType, Type: obj.Name(),
+			Err: fmt.Errorf("%s is an alias of %s; methods can't be declared on a type of another package", obj.Name(), types.TypeString(named, nil))}
+	}
+	if err := g.parseErrs[target.Name()]; err != nil {
+		return nil, err
+	}
+	return structs[target.Name()], nil
+}
+
+// definedStruct returns the struct of the defined type obj whose struct
+// comes from another type, type Wrapped other.Thing, with the fields read
+// through go/types, and nil for other types. The fields that are not
+// exported from another package are left out; the access tags of the
+// fields are read as for a struct declared in the package.
+func (g *Generator) definedStruct(obj *types.TypeName) (*StructInfo, error) {
+	named, ok := obj.Type().(*types.Named)
+	if !ok || obj.IsAlias() || named.TypeParams().Len() > 0 {
+		return nil, nil
+	}
+	s, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil
+	}
+	st := &StructInfo{Name: obj.Name()}
+	if len(g.pkg.files) > 0 {
+		st.File = g.pkg.files[0].fileSet.Position(obj.Pos()).Filename
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		if !f.Exported() && f.Pkg() != g.pkg.types {
+			continue
+		}
+		info := StructFieldInfo{Name: f.Name(), Embedded: f.Embedded(), typ: f.Type()}
+		info.Type = types.TypeString(f.Type(), func(p *types.Package) string {
+			if p == g.pkg.types {
+				return ""
+			}
+			info.Imports = append(info.Imports, Import{Path: p.Path()})
+			return p.Name()
+		})
+		if err := parseTag(&info, s.Tag(i), g.tagName, obj.Name()); err != nil {
+			return nil, err
+		}
+		st.Fields = append(st.Fields, info)
+	}
+	return st, nil
+}
+
+// newAccessor returns the template data for the accessors of a field of
+// the struct type.
+func (g *Generator) newAccessor(st *StructInfo, field StructFieldInfo) accessor {
+	a := accessor{
+		Receiver:     g.receiverName(st),
+		Struct:       st.TypeName(),
+		Field:        field.Name,
+		Name:         field.Name,
+		Type:         field.Type,
+		Zero:         zeroOf(g.fieldType(field), field.Type),
+		SkipZero:     field.HasOption(AccessSkipZero),
+		Audit:        g.audit,
+		Chain:        g.chain || field.HasOption(AccessChain),
+		ReturnsError: g.errSetters || field.HasOption(AccessErrSet),
+		Immutable:    g.immutable || field.HasOption(AccessImmutable),
+		NilSafe:      g.nilSafe || field.HasOption(AccessNilSafe),
+		Doc:          field.Doc,
+	}
+	if t := g.fieldType(field); t != nil {
+		g.knownTypes[field.Type] = t
+	}
+	kind := g.receiverKind
+	if st.ReceiverKind != "" {
+		kind = st.ReceiverKind
+	}
+	if kind == ReceiverValue {
+		// A value receiver is never nil.
+		a.ValueGetter, a.NilSafe = true, false
+	}
+	if name, ok := field.OptionValue(AccessName); ok {
+		a.Name = name
+	} else if !g.legacyNames {
+		a.Name = capitalize(initialisms(a.Name, g.initialisms))
+	}
+	name := g.funcName(st.Name, a.Name)
+	a.Getter, a.Setter = g.getterPrefix+name, g.setterPrefix+name
+	switch {
+	case field.HasOption(AccessIs):
+		a.Getter = "Is" + name
+	case field.HasOption(AccessHas):
+		a.Getter = "Has" + name
+	case g.boolPrefix != "" && isBool(g.fieldType(field)):
+		a.Getter = g.boolPrefix + name
+	}
+	if g.unexported || field.HasOption(AccessUnexported) {
+		a.Getter, a.Setter = unexport(a.Getter), unexport(a.Setter)
+	}
+	if field.Via != "" {
+		a.Field = field.Via + "." + field.Name
+		a.Embed = field.ViaPtr
+		a.EmbedType = field.ViaType
+	}
+	return a
+}
+
+// methodSet tracks the methods generated for a struct type.
+type methodSet struct {
+	typeName string
+	fields   map[string]bool   // names of the fields declared by the struct
+	methods  map[string]string // method name -> field it belongs to
+	order    []string          // methods in the order they are declared
+	readers  map[string]bool   // methods that don't modify the value
+	// handWritten are the methods declared outside the generated files,
+	// by name, with their positions. With skip the generated methods they
+	// collide with are left out rather than reported.
+	handWritten map[string]string
+	skip        bool
+}
+
+func newMethodSet(st *StructInfo) *methodSet {
+	m := &methodSet{typeName: st.Name, fields: make(map[string]bool), methods: make(map[string]string), readers: make(map[string]bool)}
+	for _, field := range st.Fields {
+		m.fields[field.Name] = true
+	}
+	return m
+}
+
+// declare records that method is generated for field of the type, and
+// reports an ErrMethodCollision if the name is already taken by another
+// method or by a field of the struct.
+func (m *methodSet) declare(method, field string) error {
+	if m.fields[method] {
+		return &Error{Kind: ErrMethodCollision, Type: m.typeName, Field: field,
+			Err: fmt.Errorf("method %s has the same name as a field", method)}
+	}
+	if pos, ok := m.handWritten[method]; ok {
+		if m.skip {
+			return errSkipMethod
+		}
+		return &Error{Kind: ErrMethodCollision, Type: m.typeName, Field: field,
+			Err: fmt.Errorf("method %s is already declared at %s", method, pos)}
+	}
+	if other, ok := m.methods[method]; ok {
+		if other == "" {
+			other = m.typeName
+		}
+		return &Error{Kind: ErrMethodCollision, Type: m.typeName, Field: field,
+			Err: fmt.Errorf("method %s is also generated for %s", method, other)}
+	}
+	m.methods[method] = field
+	m.order = append(m.order, method)
+	return nil
+}
+
+// declareAll declares the methods generated together for field. It
+// returns false when they are left out because one of them collides with
+// a hand-written method.
+func (m *methodSet) declareAll(field string, methods ...string) (bool, error) {
+	for _, method := range methods {
+		if err := m.declare(method, field); err == errSkipMethod {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// handWrittenMethods returns the methods of the named type of the current
+// package declared outside the files generated by the accessor command,
+// with their positions.
+func (g *Generator) handWrittenMethods(typeName string) map[string]string {
+	methods := make(map[string]string)
+	obj, ok := g.pkg.types.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return methods
+	}
+	named, ok := types.Unalias(obj.Type()).(*types.Named)
+	if !ok {
+		return methods
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		fn := named.Method(i)
+		if !fn.Pos().IsValid() || g.generatedFile(fn.Pos()) != "" {
+			continue
+		}
+		pos := g.pkg.files[0].fileSet.Position(fn.Pos())
+		methods[fn.Name()] = fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line)
+	}
+	return methods
+}
+
+// fields returns the fields accessors are generated for: the struct's own
+// fields followed, when g.embedded is set, by the fields promoted through
+// pointer embedded structs of the package. Own fields shadow promoted ones.
+// With g.sortFields the fields are ordered by name, ignoring case, instead.
+func (g *Generator) fields(info StructFieldInfoArr) []StructFieldInfo {
+	fields := g.ownAndPromoted(info)
+	if g.sortFields {
+		sort.SliceStable(fields, func(i, j int) bool {
+			a, b := strings.ToLower(fields[i].Name), strings.ToLower(fields[j].Name)
+			if a != b {
+				return a < b
+			}
+			return fields[i].Name < fields[j].Name
+		})
+	}
+	return fields
+}
+
+// ownAndPromoted returns the fields declared by the struct followed, with
+// -embedded, by the fields promoted from its embedded structs. Promotion
+// follows the Go rules: a field is hidden by a field of the same name at a
+// shallower depth and dropped when the name is ambiguous at its depth.
+// Only non-generic structs of the package are followed, through at most
+// one pointer embedding. An embedded field tagged access:"-" promotes
+// nothing.
+func (g *Generator) ownAndPromoted(info StructFieldInfoArr) []StructFieldInfo {
+	fields := make([]StructFieldInfo, 0, len(info))
+	seen := make(map[string]bool)
+	for _, field := range info {
+		seen[field.Name] = true
+		if !field.Embedded {
+			fields = append(fields, field)
+		}
+	}
+	if !g.embedded {
+		return fields
+	}
+	type embed struct {
+		st           *StructInfo
+		via          string // selector of the embedded struct
+		ptr, ptrType string // pointer embedded field on the way and its type
+	}
+	var level []embed
+	visited := make(map[*StructInfo]bool)
+	next := func(parent embed, field StructFieldInfo) {
+		if field.Skip {
+			return
+		}
+		st, ptr := g.embeddedStruct(field)
+		if st == nil || visited[st] || (ptr && parent.ptr != "") {
+			return
+		}
+		visited[st] = true
+		e := embed{st: st, via: field.Name, ptr: parent.ptr, ptrType: parent.ptrType}
+		if parent.via != "" {
+			e.via = parent.via + "." + field.Name
+		}
+		if ptr {
+			e.ptr, e.ptrType = e.via, st.Name
+		}
+		level = append(level, e)
+	}
+	for _, field := range info {
+		if field.Embedded {
+			next(embed{}, field)
+		}
+	}
+	for len(level) > 0 {
+		count := make(map[string]int)
+		for _, e := range level {
+			for _, field := range e.st.Fields {
+				count[field.Name]++
+			}
+		}
+		current := level
+		level = nil
+		for _, e := range current {
+			for _, promoted := range e.st.Fields {
+				if seen[promoted.Name] || count[promoted.Name] > 1 {
+					continue
+				}
+				if promoted.Embedded {
+					next(e, promoted)
+					continue
+				}
+				promoted.Via, promoted.ViaPtr, promoted.ViaType = e.via, e.ptr, e.ptrType
+				fields = append(fields, promoted)
+			}
+		}
+		for name := range count {
+			seen[name] = true
+		}
+	}
+	return fields
+}
+
+// embeddedStruct returns the struct of the package embedded by field and
+// whether it is embedded through a pointer. It returns nil for types of
+// other packages and generic structs.
+func (g *Generator) embeddedStruct(field StructFieldInfo) (st *StructInfo, ptr bool) {
+	expr := field.expr
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, ptr = star.X, true
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	st, ok = g.structInfo[ident.Name]
+	if !ok || len(st.TypeParams) > 0 {
+		return nil, false
+	}
+	return st, ptr
+}
+
+// ListTypes prints every struct type of the current package together with
+// the number of readable and writable fields and whether any field carries
+// an access tag. The types that fail to parse are left out and their
+// errors returned.
+func (g *Generator) ListTypes(w io.Writer) error {
+	all, err := g.loadStructs()
+	if err != nil {
+		return err
+	}
+	names, err := g.StructNames()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "TYPE\tREAD\tWRITE\tTAGGED\n")
+	for _, stName := range names {
+		var read, write int
+		if err := g.parseErrs[stName]; err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tagged := false
+		for _, field := range g.fields(all[stName].Fields) {
+			for _, access := range field.Access {
+				switch access {
+				case AccessRead:
+					read++
+				case AccessWrite:
+					write++
+				}
+			}
+			tagged = tagged || field.Tagged
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%t\n", stName, read, write, tagged)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+type StructFieldInfo struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Access  []string `json:"access,omitempty"`
+	Options []string `json:"options,omitempty"` // tag options other than the access modes
+	Tagged  bool     `json:"tagged,omitempty"`  // access is set explicitly by the struct tag
+	Skip    bool     `json:"skip,omitempty"`    // the field is excluded with access:"-"
+	Tag     string   `json:"tag,omitempty"`     // the raw struct tag
+	Doc     string   `json:"doc,omitempty"`     // text of the doc comment of the field, or else of its line comment
+	Imports []Import `json:"imports,omitempty"` // imports referenced by Type
+	// Embedded is set for an embedded field; Name is then the name of
+	// the embedded type.
+	Embedded bool `json:"embedded,omitempty"`
+	// Via is the selector of the embedded struct a promoted field is
+	// reached through. ViaPtr is the selector of the pointer embedded
+	// field on the way, if any, and ViaType the struct type it points to.
+	Via     string `json:"via,omitempty"`
+	ViaPtr  string `json:"viaPtr,omitempty"`
+	ViaType string `json:"viaType,omitempty"`
+	expr    ast.Expr
+	typ     types.Type // checked type of a field read from go/types, which has no expr
+}
+
+// HasAccess reports whether the field has the access mode r or w.
+func (f StructFieldInfo) HasAccess(mode string) bool {
+	for _, access := range f.Access {
+		if access == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOption reports whether the field's tag carries the named option.
+func (f StructFieldInfo) HasOption(name string) bool {
+	for _, opt := range f.Options {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// OptionValue returns the value of the key=value option of the field's tag.
+func (f StructFieldInfo) OptionValue(key string) (string, bool) {
+	for _, opt := range f.Options {
+		if strings.HasPrefix(opt, key+"=") {
+			return opt[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// TagValue returns the name part of the field's tag with the given key,
+// e.g. "user_name" for db:"user_name,omitempty".
+func (f StructFieldInfo) TagValue(key string) (string, bool) {
+	tags, err := structtag.Parse(f.Tag)
+	if err != nil {
+		return "", false
+	}
+	tag, err := tags.Get(key)
+	if err != nil {
+		return "", false
+	}
+	return tag.Name, true
+}
+
+type StructFieldInfoArr = []StructFieldInfo
+
+// StructInfo describes a struct type declaration.
+type StructInfo struct {
+	Name       string
+	File       string // name of the file declaring the type, if known
+	TypeParams []TypeParam
+	Fields     StructFieldInfoArr
+	// ReceiverKind is set by an //accessor:receiver=value or
+	// //accessor:receiver=pointer directive in the type's doc comment.
+	ReceiverKind string
+	// Equal is set by an //accessor:equal directive in the type's doc
+	// comment.
+	Equal bool
+}
+
+// TypeParam is a type parameter of a generic struct type.
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// TypeParamsDecl returns the type parameter list with constraints, as
+// written in a type declaration: [K comparable, V any].
+func (s *StructInfo) TypeParamsDecl() string {
+	if len(s.TypeParams) == 0 {
+		return ""
+	}
+	params := make([]string, len(s.TypeParams))
+	for i, tp := range s.TypeParams {
+		params[i] = tp.Name + " " + tp.Constraint
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// TypeArgs returns the type parameter names as written when the type is
+// instantiated with them: [K, V].
+func (s *StructInfo) TypeArgs() string {
+	return strings.TrimPrefix(s.TypeName(), s.Name)
+}
+
+// TypeName returns the type as written in a method receiver, with its
+// type parameters: Box[T] for type Box[T any] struct{...}.
+func (s *StructInfo) TypeName() string {
+	if len(s.TypeParams) == 0 {
+		return s.Name
+	}
+	names := make([]string, len(s.TypeParams))
+	for i, tp := range s.TypeParams {
+		names[i] = tp.Name
+	}
+	return s.Name + "[" + strings.Join(names, ", ") + "]"
+}
+
+// ParseStruct returns the struct types declared in file. A struct type
+// that can't be parsed is left out of the result and reported in the
+// returned error, which joins one *Error per such type; the other types
+// are still returned.
+func ParseStruct(file *ast.File, fileSet *token.FileSet, tagName string) (structMap map[string]*StructInfo, err error) {
+	structMap = make(map[string]*StructInfo)
+	imports := fileImports(file)
+
+	var errs []error
+	var declDoc *ast.CommentGroup // doc comment of a type declaration with a single spec
+	collectStructs := func(x ast.Node) bool {
+		if _, ok := x.(*ast.FuncDecl); ok {
+			// Types declared in functions can't have methods.
+			return false
+		}
+		if decl, ok := x.(*ast.GenDecl); ok {
+			declDoc = nil
+			if len(decl.Specs) == 1 {
+				declDoc = decl.Doc
+			}
+			return true
+		}
+		ts, ok := x.(*ast.TypeSpec)
+		if !ok || ts.Type == nil {
+			return true
+		}
+
+		// 获取结构体名称
+		structName := ts.Name.Name
+		if ts.Assign.IsValid() {
+			// Methods can't be declared on aliases of struct literals.
+			return false
+		}
+
+		s, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		st := &StructInfo{Name: structName}
+		if fileSet != nil {
+			st.File = fileSet.Position(ts.Pos()).Filename
+		}
+		doc := ts.Doc
+		if doc == nil {
+			doc = declDoc
+		}
+		if doc != nil {
+			for _, c := range doc.List {
+				if strings.TrimSpace(c.Text) == equalDirective {
+					st.Equal = true
+				}
+				if !strings.HasPrefix(c.Text, receiverDirective) {
+					continue
+				}
+				st.ReceiverKind = strings.TrimSpace(c.Text[len(receiverDirective):])
+				if st.ReceiverKind != ReceiverValue && st.ReceiverKind != ReceiverPointer {
+					errs = append(errs, &Error{Kind: ErrParse, Type: structName,
+						Err: fmt.Errorf("%s%s: want %s or %s", receiverDirective, st.ReceiverKind, ReceiverValue, ReceiverPointer)})
+					return false
+				}
+			}
+		}
+		if ts.TypeParams != nil {
+			for _, field := range ts.TypeParams.List {
+				var constraint bytes.Buffer
+				if perr := printer.Fprint(&constraint, fileSet, field.Type); perr != nil {
+					errs = append(errs, &Error{Kind: ErrParse, Type: structName, Err: perr})
+					return false
+				}
+				for _, name := range field.Names {
+					st.TypeParams = append(st.TypeParams, TypeParam{Name: name.Name, Constraint: constraint.String()})
+				}
+			}
+		}
+		fileInfos := make([]StructFieldInfo, 0)
+		for _, field := range s.Fields.List {
+			// X, Y int declares a field for every name, sharing the tag.
+			var names []string
+			if len(field.Names) == 0 {
+				name := embeddedName(field.Type)
+				if name == "" {
+					continue
+				}
+				names = append(names, name)
+			}
+			for _, ident := range field.Names {
+				names = append(names, ident.Name)
+			}
+			for _, name := range names {
+				info := StructFieldInfo{
+					Name:     name,
+					Embedded: len(field.Names) == 0,
+					Imports:  exprImports(field.Type, imports),
+					expr:     field.Type,
+				}
+				var typeNameBuf bytes.Buffer
+				if perr := printer.Fprint(&typeNameBuf, fileSet, field.Type); perr != nil {
+					errs = append(errs, &Error{Kind: ErrParse, Type: structName, Field: name, Err: perr})
+					return false
+				}
+
+				info.Type = typeNameBuf.String()
+				if doc := field.Doc; doc != nil {
+					info.Doc = doc.Text()
+				} else if field.Comment != nil {
+					info.Doc = field.Comment.Text()
+				}
+				var tag string
+				if field.Tag != nil { // 有tag
+					tag = strings.Trim(field.Tag.Value, "`")
+				}
+				if perr := parseTag(&info, tag, tagName, structName); perr != nil {
+					errs = append(errs, perr)
+					return false
+				}
+				fileInfos = append(fileInfos, info)
+			}
+		}
+		st.Fields = fileInfos
+		structMap[structName] = st
+		return false
+	}
+
+	ast.Inspect(file, collectStructs)
+	return structMap, errors.Join(errs...)
+}
+
+// parseTag sets the access modes and options of the field from the key
+// tagName of its struct tag. A field without it is readable and, when
+// exported, writable.
+func parseTag(info *StructFieldInfo, tag, tagName, structName string) error {
+	name := info.Name
+	if tag != "" {
+		info.Tag = tag
+		tags, err := structtag.Parse(tag)
+		if err != nil {
+			return &Error{Kind: ErrParse, Type: structName, Field: name, Err: err}
+		}
+		access, terr := tags.Get(tagName)
+		if terr == nil && access.Name == AccessSkip {
+			info.Skip = true
+			info.Tagged = true
+		} else if terr == nil {
+			for _, v := range append([]string{access.Name}, access.Options...) {
+				if v == AccessRead || v == AccessWrite {
+					info.Access = append(info.Access, v)
+				} else if v != "" {
+					info.Options = append(info.Options, v)
+				}
+			}
+			if n, ok := info.OptionValue(AccessName); ok && !token.IsIdentifier(n) {
+				return &Error{Kind: ErrParse, Type: structName, Field: name,
+					Err: fmt.Errorf("%s=%q is not a valid identifier", AccessName, n)}
+			}
+			info.Tagged = true
+		}
+	}
+	if !info.Tagged {
+		firstChar := name[0:1]
+		if strings.ToUpper(firstChar) == firstChar { //大写
+			info.Access = []string{AccessRead, AccessWrite}
+		} else { // 小写
+			info.Access = []string{AccessRead}
+		}
+	}
+	return nil
+}
+
+// embeddedName returns the field name of an embedded field of type expr.
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return ""
+}
+
+// accessor is the data handed to the getter and setter templates.
+type accessor struct {
+	Receiver string
+	Struct   string
+	Field    string // selector of the field, relative to the receiver
+	Name     string // field name used in the method names
+	Getter   string // getter method name
+	Setter   string // setter method name
+	Type     string
+	Zero     string // zero value of Type
+	SkipZero bool   // setter leaves the field untouched for zero inputs
+	Audit    bool   // setter calls auditLog with the old and new value
+	Chain    bool   // setter returns the receiver
+	// Immutable replaces the setter with a value receiver With<Name>
+	// method returning a modified copy.
+	Immutable bool
+	NilSafe   bool // getter returns Zero for a nil receiver
+	// ValueGetter makes the getters use a value receiver.
+	ValueGetter bool
+	// Clone is "slice" or "map" when the getter returns a copy.
+	Clone string
+	// Lock is the mutex field the accessors lock, RLock is set when the
+	// getter takes a read lock on it.
+	Lock  string
+	RLock bool
+	// Load and Store, when set, replace reading the field and assigning
+	// param to it.
+	Load  string
+	Store string
+	// Embed is the pointer embedded field a promoted field is reached
+	// through, EmbedType the struct type it points to. The accessors
+	// guard against Embed being nil.
+	Embed     string
+	EmbedType string
+	// Checks are the constraints the setter enforces, Validate what it
+	// does with a value breaking one: ValidatePanic, ValidateClamp or
+	// ValidateError. ReturnsError is set with ValidateError and the errset
+	// option, and the setter then returns an error; Validate is never
+	// ValidatePanic with it.
+	Checks       []check
+	Validate     string
+	ReturnsError bool
+	// BeforeSet and AfterSet are the hook methods the setter calls with
+	// the old and new value before and after assigning the field.
+	// BeforeSetErr is set when BeforeSet returns an error.
+	BeforeSet    string
+	BeforeSetErr bool
+	AfterSet     string
+	// Changes is the map[string]bool field the setter records the field
+	// in, with -track-changes.
+	Changes string
+	// Observers is the map[string][]interface{} field holding the
+	// functions the setter calls with the old and new value, with
+	// -observers.
+	Observers string
+	// Doc is the documentation of the field, copied onto its getter and
+	// setter.
+	Doc string
+}
+
+// Deprecated returns the paragraph of a.Doc starting with "Deprecated:"
+// as comment lines, for the methods of a deprecated field to be flagged
+// too, or "" if there is none.
+func (a accessor) Deprecated() string {
+	for _, para := range strings.Split(strings.TrimRight(a.Doc, "\n"), "\n\n") {
+		if strings.HasPrefix(para, "Deprecated:") {
+			return "// " + strings.ReplaceAll(para, "\n", "\n// ")
+		}
+	}
+	return ""
+}
+
+// IsZero returns the condition testing x against the zero value of the
+// field type. Composite literals are parenthesized so the condition can
+// be used in an if statement.
+func (a accessor) IsZero(x string) string {
+	if strings.HasSuffix(a.Zero, "}") {
+		return x + " == (" + a.Zero + ")"
+	}
+	return x + " == " + a.Zero
+}
+
+// printAccessor renders the accessor template name into the output of the
+// type, as functions with -funcs, after the doc comment of the field.
+func (g *Generator) printAccessor(stName, name string, a accessor) error {
+	g.rendering = stName
+	method, err := g.render(name, a)
+	if err != nil {
+		return err
+	}
+	if g.funcs {
+		structs, _ := g.loadStructs()
+		rename := map[string]string{"With" + a.Name: "With" + stName + a.Name}
+		if method, err = g.toFunc(structs[stName], method, rename); err != nil {
+			if _, ok := err.(*Error); ok {
+				return err
+			}
+			return &Error{Kind: ErrTemplate, Type: a.Struct, Field: a.Field, Err: err}
+		}
+	}
+	if a.Doc != "" {
+		g.Printf(stName, "%s", g.accessorDoc(stName, name, a))
+	}
+	g.Printf(stName, "%s\n", method)
+	return nil
+}
+
+// accessorDoc returns the doc comment of the getter, setter or With method
+// of a field documented by a.Doc: a sentence naming the field followed by
+// its documentation. The other accessors only get its deprecation notice.
+func (g *Generator) accessorDoc(stName, name string, a accessor) string {
+	var summary string
+	switch name {
+	case "getter.tmpl":
+		summary = fmt.Sprintf("%s returns %s.", a.Getter, a.Field)
+	case "setter.tmpl":
+		summary = fmt.Sprintf("%s sets %s.", a.Setter, a.Field)
+	case "wither.tmpl":
+		with := "With" + a.Name
+		if g.funcs {
+			with = "With" + stName + a.Name
+		}
+		summary = fmt.Sprintf("%s returns a copy with %s set.", with, a.Field)
+	default:
+		if notice := a.Deprecated(); notice != "" {
+			return notice + "\n"
+		}
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("// " + summary + "\n//\n")
+	for _, line := range strings.Split(strings.TrimRight(a.Doc, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// genColumn produces the method returning the column of the field whose
+// accessors are named after name, on the receiver type recv.
+func genColumn(recv, name, column string) string {
+	return fmt.Sprintf("func (%s) %sColumn() string {\n\treturn %s\n}", recv, name, strconv.Quote(column))
+}
@@ -0,0 +1,313 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// moduleRoot is the directory of the accessor module, which the test
+// packages require for the packages the generated code imports.
+var moduleRoot, _ = filepath.Abs("..")
+
+// testPackage writes files to the package example.com/p of a temporary
+// module and returns its directory.
+func testPackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	sum, err := os.ReadFile(filepath.Join(moduleRoot, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["go.mod"] = "module example.com/p\n\ngo 1.25\n\n" +
+		"require github.com/lazypandatg/accessor v0.0.0\n\n" +
+		"replace github.com/lazypandatg/accessor => " + moduleRoot + "\n"
+	files["go.sum"] = string(sum)
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// chdir makes dir the working directory until the end of the test; the
+// packages are loaded relative to it.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// load returns a generator configured by cfg with the package in dir
+// loaded.
+func load(t *testing.T, cfg Config, dir string) *Generator {
+	t.Helper()
+	g, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+	if err := g.Load("."); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// generate returns the output generated by cfg for the types of the
+// package in dir.
+func generate(t *testing.T, cfg Config, dir string, typeNames ...string) string {
+	t.Helper()
+	g := load(t, cfg, dir)
+	g.SetTypes(typeNames)
+	for _, typeName := range typeNames {
+		if err := g.Generate(typeName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := g.Bytes(typeNames...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+// generateErr returns the error of generating the type of the package in
+// dir with cfg.
+func generateErr(t *testing.T, cfg Config, dir, typeName string) error {
+	t.Helper()
+	g := load(t, cfg, dir)
+	g.SetTypes([]string{typeName})
+	return g.Generate(typeName)
+}
+
+// runTests writes the generated src and the test file to the package in
+// dir and runs go vet and go test on it.
+func runTests(t *testing.T, dir, src, test string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	files := map[string]string{"accessor_gen.go": src, "p_test.go": test}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"vet", "."}, {"test", "."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %s\n%s\ngenerated:\n%s", args[0], err, out, src)
+		}
+	}
+}
+
+// golden compares src to the golden file testdata/name, which -update
+// rewrites.
+func golden(t *testing.T, name, src string) {
+	t.Helper()
+	path := filepath.Join(moduleRoot, "gen", "testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src != string(want) {
+		t.Errorf("output differs from %s:\n%s", path, UnifiedDiff(path, "output", want, []byte(src)))
+	}
+}
+
+// contains fails the test unless src holds each of the lines.
+func contains(t *testing.T, src string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(src, line) {
+			t.Errorf("output lacks %q:\n%s", line, src)
+		}
+	}
+}
+
+func TestListTypes(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	Name  string ` + "`access:\"r,w\"`" + `
+	Email string ` + "`access:\"r\"`" + `
+	age   int
+}
+
+type Point struct {
+	X, Y int
+}
+`})
+	g := load(t, DefaultConfig(), dir)
+	var b bytes.Buffer
+	if err := g.ListTypes(&b); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		got = append(got, strings.Join(strings.Fields(line), " "))
+	}
+	want := []string{
+		"TYPE READ WRITE TAGGED",
+		"Point 2 2 false",
+		"User 3 1 true",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ListTypes printed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPromotedThroughPointer(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Base struct {
+	ID int
+}
+
+type Outer struct {
+	*Base
+	Name string
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Embedded = true
+	src := generate(t, cfg, dir, "Outer")
+	contains(t, src, "if o.Base == nil {\n\t\treturn 0\n\t}\n\treturn o.Base.ID", "o.Base = &Base{}")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestPromoted(t *testing.T) {
+	var o Outer
+	if id := o.GetID(); id != 0 {
+		t.Errorf("GetID() on a nil Base = %d", id)
+	}
+	o.SetID(7)
+	if o.Base == nil || o.Base.ID != 7 || o.GetID() != 7 {
+		t.Errorf("SetID(7) left %+v", o.Base)
+	}
+}
+`)
+}
+
+func TestColumns(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import "sync"
+
+type User struct {
+	mu       sync.Mutex ` + "`access:\"mutex\"`" + `
+	id       int        ` + "`db:\"user_id\"`" + `
+	Name     string
+	userName string     ` + "`access:\"r,name=Login\" db:\"login\"`" + `
+	Secret   string     ` + "`access:\"-\" db:\"secret\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.ColumnTag = "db"
+	src := generate(t, cfg, dir, "User")
+	if strings.Contains(src, "SecretColumn") {
+		t.Errorf("column method generated for a field tagged access:\"-\":\n%s", src)
+	}
+	if strings.Contains(src, "MuColumn") {
+		t.Errorf("column method generated for the mutex:\n%s", src)
+	}
+	contains(t, src, "func (*User) IDColumn() string {")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestColumns(t *testing.T) {
+	u := &User{}
+	for _, c := range []struct{ got, want string }{
+		{u.IDColumn(), "user_id"},
+		{u.NameColumn(), "Name"},
+		{u.LoginColumn(), "login"},
+	} {
+		if c.got != c.want {
+			t.Errorf("column %q, want %q", c.got, c.want)
+		}
+	}
+}
+`)
+}
+
+func TestSortFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Config struct {
+	port    int
+	Host    string
+	debug   bool
+	Address string
+}
+`})
+	cfg := DefaultConfig()
+	cfg.SortFields = true
+	golden(t, "sort_fields.golden", generate(t, cfg, dir, "Config"))
+}
+
+func TestGenericAlias(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import "example.com/p/sets"
+
+type Set[T comparable] = map[T]struct{}
+
+type Group struct {
+	Tags Set[string]
+	IDs  sets.Set[int]
+}
+`})
+	if err := os.Mkdir(filepath.Join(dir, "sets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sets", "sets.go"), []byte("package sets\n\ntype Set[T comparable] = map[T]struct{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := generate(t, DefaultConfig(), dir, "Group")
+	contains(t, src,
+		`"example.com/p/sets"`,
+		"func (g *Group) GetTags() Set[string] {",
+		"func (g *Group) SetTags(param Set[string]) {",
+		"func (g *Group) GetIDs() sets.Set[int] {",
+		"func (g *Group) SetIDs(param sets.Set[int]) {")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestGroup(t *testing.T) {
+	var g Group
+	g.SetIDs(map[int]struct{}{1: {}})
+	if _, ok := g.GetIDs()[1]; !ok {
+		t.Error("GetIDs lacks 1")
+	}
+}
+`)
+
+	err := generateErr(t, DefaultConfig(), dir, "Set")
+	if !errors.Is(err, ErrNotStruct) {
+		t.Errorf("generating the alias Set: %v, want %v", err, ErrNotStruct)
+	}
+}
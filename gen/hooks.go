@@ -0,0 +1,80 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// Prefixes of the hook methods a setter calls when the type declares them:
+// beforeSet<Name>(old, new T) error, or without the result, before the
+// field is assigned and afterSet<Name>(old, new T) after it.
+const (
+	beforeSetHook = "beforeSet"
+	afterSetHook  = "afterSet"
+)
+
+// hooks sets the hooks of the field's setter in a to the hook methods the
+// type declares, checking their signatures. An error of the before hook is
+// returned by a setter returning errors and panics otherwise.
+func (g *Generator) hooks(stName string, a *accessor, field StructFieldInfo) error {
+	t := g.fieldType(field)
+	if t == nil {
+		return nil
+	}
+	if fn := g.declaredMethod(stName, beforeSetHook+a.Name); fn != nil {
+		sig := fn.Signature()
+		if !hookParams(sig, t) || sig.Results().Len() > 1 ||
+			sig.Results().Len() == 1 && !types.Identical(sig.Results().At(0).Type(), types.Universe.Lookup("error").Type()) {
+			return &Error{Kind: ErrHookSignature, Type: stName, Field: field.Name,
+				Err: fmt.Errorf("%s must be func(old, new %s) or func(old, new %s) error", fn.Name(), field.Type, field.Type)}
+		}
+		a.BeforeSet, a.BeforeSetErr = fn.Name(), sig.Results().Len() == 1
+	}
+	if fn := g.declaredMethod(stName, afterSetHook+a.Name); fn != nil {
+		if sig := fn.Signature(); !hookParams(sig, t) || sig.Results().Len() > 0 {
+			return &Error{Kind: ErrHookSignature, Type: stName, Field: field.Name,
+				Err: fmt.Errorf("%s must be func(old, new %s)", fn.Name(), field.Type)}
+		}
+		a.AfterSet = fn.Name()
+	}
+	return nil
+}
+
+// hookParams reports whether sig takes two parameters of type t.
+func hookParams(sig *types.Signature, t types.Type) bool {
+	return sig.Params().Len() == 2 && !sig.Variadic() &&
+		types.Identical(sig.Params().At(0).Type(), t) && types.Identical(sig.Params().At(1).Type(), t)
+}
+
+// declaredMethod returns the method name of the type typeName declared in
+// a file of the package that is not generated, or nil if there is none.
+// The methods of a generic type are given in terms of the type parameters
+// of its declaration, whatever the names in their receivers.
+func (g *Generator) declaredMethod(typeName, name string) *types.Func {
+	obj, ok := g.pkg.types.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, ok := types.Unalias(obj.Type()).(*types.Named)
+	if !ok {
+		return nil
+	}
+	if tparams := named.TypeParams(); tparams.Len() > 0 {
+		targs := make([]types.Type, tparams.Len())
+		for i := range targs {
+			targs[i] = tparams.At(i)
+		}
+		inst, err := types.Instantiate(nil, named, targs, false)
+		if err != nil {
+			return nil
+		}
+		named = inst.(*types.Named)
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		fn := named.Method(i)
+		if fn.Name() == name && fn.Pos().IsValid() && g.generatedFile(fn.Pos()) == "" {
+			return fn
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Import is an import a generated file needs. Name is set when the
+// package is imported under a name other than its own.
+type Import struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path"`
+}
+
+// fileImports returns the imports of file keyed by the name they are
+// referred to in the file. Dot and blank imports are left out.
+func fileImports(file *ast.File) map[string]Import {
+	imports := make(map[string]Import)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		imp := Import{Path: path}
+		name := guessPackageName(path)
+		if spec.Name != nil {
+			if spec.Name.Name == "_" || spec.Name.Name == "." {
+				continue
+			}
+			imp.Name = spec.Name.Name
+			name = spec.Name.Name
+		}
+		imports[name] = imp
+	}
+	return imports
+}
+
+// guessPackageName returns the name a package is most likely declared
+// with, given its import path: the last path element without a major
+// version suffix, so "gopkg.in/yaml.v3" and "github.com/a/b/v2" give
+// "yaml" and "b".
+func guessPackageName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elems[len(elems)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// exprImports returns the imports referenced by the qualified identifiers
+// of the type expression expr.
+func exprImports(expr ast.Expr, table map[string]Import) []Import {
+	var imports []Import
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok {
+			if imp, ok := table[id.Name]; ok {
+				imports = append(imports, imp)
+			}
+		}
+		return false
+	})
+	return imports
+}
+
+// addImport records that the output for the type needs imp.
+func (g *Generator) addImport(stName string, imp Import) {
+	if g.imports == nil {
+		g.imports = make(map[string]map[string]string)
+	}
+	if g.imports[stName] == nil {
+		g.imports[stName] = make(map[string]string)
+	}
+	if _, ok := g.imports[stName][imp.Path]; !ok || imp.Name != "" {
+		g.imports[stName][imp.Path] = imp.Name
+	}
+}
+
+// importName returns the name package p is referred to by in the output
+// for the type, adding the import if needed.
+func (g *Generator) importName(stName string, p *types.Package) string {
+	if name := g.imports[stName][p.Path()]; name != "" {
+		return name
+	}
+	g.addImport(stName, Import{Path: p.Path()})
+	return p.Name()
+}
+
+// importDecl returns the import declaration of the output for the types.
+func (g *Generator) importDecl(stNames ...string) string {
+	imports := make(map[string]string)
+	for _, stName := range stNames {
+		for path, name := range g.imports[stName] {
+			if imports[path] == "" {
+				imports[path] = name
+			}
+		}
+	}
+	if len(imports) == 0 {
+		return ""
+	}
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range paths {
+		if name := imports[path]; name != "" {
+			fmt.Fprintf(&b, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// methodSignature matches the first line of a generated method and
+// captures its name and signature.
+var methodSignature = regexp.MustCompile(`(?m)^func \([^)]*\) (.+) \{$`)
+
+// signatures returns the signatures of the methods declared in code, in
+// the form they take in an interface.
+func signatures(code string) []string {
+	var sigs []string
+	for _, m := range methodSignature.FindAllStringSubmatch(code, -1) {
+		sigs = append(sigs, m[1])
+	}
+	return sigs
+}
+
+// genInterface produces the <Type>Accessor interface declaring the
+// methods with the given signatures.
+func genInterface(st *StructInfo, sigs []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n// %sAccessor is the interface of the accessors of %s.\n", st.Name, st.Name)
+	fmt.Fprintf(&b, "type %sAccessor%s interface {\n", st.Name, st.TypeParamsDecl())
+	for _, sig := range sigs {
+		fmt.Fprintf(&b, "\t%s\n", sig)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// genMock produces Mock<Type>Accessor, a stub implementing the
+// <Type>Accessor interface: every call is recorded in Calls and methods
+// with results return the values of the <Method>Result fields, numbered
+// <Method>Result0, <Method>Result1 when there are several.
+func genMock(st *StructInfo, sigs []string) (string, error) {
+	mock := "Mock" + st.Name + "Accessor"
+	var fields, methods strings.Builder
+	for _, sig := range sigs {
+		name, args, results, err := parseSignature(sig)
+		if err != nil {
+			return "", &Error{Kind: ErrParse, Type: st.Name, Err: err}
+		}
+		for n, typ := range results {
+			field := name + "Result"
+			if len(results) > 1 {
+				field += strconv.Itoa(n)
+			}
+			fmt.Fprintf(&fields, "\t%s %s\n", field, typ)
+			results[n] = "m." + field
+		}
+
+		fmt.Fprintf(&methods, "\nfunc (m *%s%s) %s {\n", mock, st.TypeArgs(), sig)
+		fmt.Fprintf(&methods, "\tm.Calls = append(m.Calls, %sCall{Method: %q", mock, name)
+		if len(args) > 0 {
+			for n := range args {
+				args[n] = strings.TrimSuffix(args[n], "...")
+			}
+			fmt.Fprintf(&methods, ", Args: []interface{}{%s}", strings.Join(args, ", "))
+		}
+		methods.WriteString("})\n")
+		if len(results) > 0 {
+			fmt.Fprintf(&methods, "\treturn %s\n", strings.Join(results, ", "))
+		}
+		methods.WriteString("}\n")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n// %s is a stub of %sAccessor for tests. Its methods record\n", mock, st.Name)
+	b.WriteString("// their calls in Calls and return the values of the <Method>Result fields.\n")
+	fmt.Fprintf(&b, "type %s%s struct {\n", mock, st.TypeParamsDecl())
+	b.WriteString(fields.String())
+	fmt.Fprintf(&b, "\tCalls []%sCall\n}\n", mock)
+	fmt.Fprintf(&b, "\n// %sCall is a call recorded by %s.\n", mock, mock)
+	fmt.Fprintf(&b, "type %sCall struct {\n\tMethod string\n\tArgs   []interface{}\n}\n", mock)
+	b.WriteString(methods.String())
+	return b.String(), nil
+}
+
+// parseSignature returns the name, the parameter names and the result
+// types of the method signature sig. A variadic parameter name is followed
+// by "...", as in a call passing it on.
+func parseSignature(sig string) (name string, args, results []string, err error) {
+	expr, err := parser.ParseExpr("interface{" + sig + "}")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	method := expr.(*ast.InterfaceType).Methods.List[0]
+	fn := method.Type.(*ast.FuncType)
+	for _, param := range fn.Params.List {
+		for _, ident := range param.Names {
+			arg := ident.Name
+			if _, ok := param.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	if fn.Results != nil {
+		for _, result := range fn.Results.List {
+			results = append(results, types.ExprString(result.Type))
+		}
+	}
+	return method.Names[0].Name, args, results, nil
+}
+
+// genView produces <Type>View, a read-only wrapper of a *<Type> with the
+// methods of sigs that are readers, and the View method returning it.
+func genView(st *StructInfo, receiver string, sigs []string, readers map[string]bool) (string, error) {
+	view := st.Name + "View"
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n// %s gives read-only access to a *%s.\n", view, st.Name)
+	fmt.Fprintf(&b, "type %s%s struct {\n\tptr *%s\n}\n", view, st.TypeParamsDecl(), st.TypeName())
+	fmt.Fprintf(&b, "\n// View returns a read-only view of %s.\n", receiver)
+	fmt.Fprintf(&b, "func (%s *%s) View() %s%s {\n\treturn %s%s{ptr: %s}\n}\n",
+		receiver, st.TypeName(), view, st.TypeArgs(), view, st.TypeArgs(), receiver)
+	for _, sig := range sigs {
+		name, args, _, err := parseSignature(sig)
+		if err != nil {
+			return "", &Error{Kind: ErrParse, Type: st.Name, Err: err}
+		}
+		if !readers[name] {
+			continue
+		}
+		fmt.Fprintf(&b, "\nfunc (v %s%s) %s {\n\treturn v.ptr.%s(%s)\n}\n", view, st.TypeArgs(), sig, name, strings.Join(args, ", "))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,41 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterfaceUnexported(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	name  string ` + "`access:\"r,w\"`" + `
+	token string ` + "`access:\"r,w,unexported\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Interface = true
+	cfg.Mock = true
+	src := generate(t, cfg, dir, "User")
+	contains(t, src, "\tGetName() string\n", "\tSetName(param string)\n")
+	if strings.Contains(src, "\tgetToken() string\n") || strings.Contains(src, "MockUserAccessor) getToken") {
+		t.Errorf("the interface lists unexported accessors:\n%s", src)
+	}
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+var (
+	_ UserAccessor = (*User)(nil)
+	_ UserAccessor = (*MockUserAccessor)(nil)
+)
+
+func TestToken(t *testing.T) {
+	u := &User{}
+	u.setToken("a")
+	if u.getToken() != "a" {
+		t.Error("setToken had no effect")
+	}
+}
+`)
+}
@@ -0,0 +1,90 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// lock is the mutex field guarding the accessors of a struct type.
+type lock struct {
+	Field string // selector of the mutex field
+	RW    bool   // the field is a sync.RWMutex; getters take a read lock
+}
+
+// structLock returns the mutex the accessors of st lock, with -threadsafe
+// or a field tagged sync, and nil when they take none.
+func (g *Generator) structLock(st *StructInfo) *lock {
+	for _, field := range st.Fields {
+		if g.threadSafe || field.HasOption(AccessSync) {
+			mu, _ := g.lockField(st)
+			return mu
+		}
+	}
+	return nil
+}
+
+// readLock writes the statements locking mu through x for reading until
+// the function returns, as the getters do.
+func (mu *lock) readLock(w *codeWriter, x string) {
+	lock, unlock := mu.rlock(x)
+	w.line("%s", lock)
+	w.line("defer %s", unlock)
+}
+
+// rlock returns the statements locking and unlocking mu through x for
+// reading.
+func (mu *lock) rlock(x string) (lock, unlock string) {
+	if mu.RW {
+		return x + "." + mu.Field + ".RLock()", x + "." + mu.Field + ".RUnlock()"
+	}
+	return x + "." + mu.Field + ".Lock()", x + "." + mu.Field + ".Unlock()"
+}
+
+// lockField finds the mutex the thread-safe accessors of st lock: the field
+// tagged access:"mutex", or else the only sync.Mutex or sync.RWMutex field,
+// embedded or not. It returns an ErrMissingField error when there is none
+// or when the choice is ambiguous.
+func (g *Generator) lockField(st *StructInfo) (*lock, error) {
+	var found []*lock
+	for _, field := range st.Fields {
+		rw, ok := g.mutexType(g.fieldType(field))
+		if field.HasOption(AccessMutex) {
+			if !ok {
+				return nil, &Error{Kind: ErrUnsupported, Type: st.Name, Field: field.Name,
+					Err: fmt.Errorf("%s option needs a sync.Mutex or sync.RWMutex, got %s", AccessMutex, field.Type)}
+			}
+			return &lock{Field: field.Name, RW: rw}, nil
+		}
+		if ok {
+			found = append(found, &lock{Field: field.Name, RW: rw})
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, &Error{Kind: ErrMissingField, Type: st.Name,
+			Err: fmt.Errorf("thread-safe accessors need a sync.Mutex or sync.RWMutex field")}
+	case 1:
+		return found[0], nil
+	}
+	return nil, &Error{Kind: ErrAmbiguousField, Type: st.Name,
+		Err: fmt.Errorf("several mutex fields; tag the one to lock with %s:%q", g.tagName, AccessMutex)}
+}
+
+// mutexType reports whether t is sync.Mutex or sync.RWMutex, or a pointer
+// to one, and whether it is the RWMutex.
+func (g *Generator) mutexType(t types.Type) (rw, ok bool) {
+	if p, isPtr := t.(*types.Pointer); isPtr {
+		t = p.Elem()
+	}
+	named, isNamed := t.(*types.Named)
+	if !isNamed || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "sync" {
+		return false, false
+	}
+	switch named.Obj().Name() {
+	case "RWMutex":
+		return true, true
+	case "Mutex":
+		return false, true
+	}
+	return false, false
+}
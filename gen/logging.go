@@ -0,0 +1,220 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// logField is a readable field of a struct logged through its getter.
+type logField struct {
+	Key    string     // name of the field
+	Getter string     // getter returning the value
+	typ    types.Type // type of the field
+	atomic bool       // the getter loads the field atomically
+}
+
+// logFields returns the readable fields of st making up its value, but
+// those tagged secret, with their getters. Embedded fields, which have no
+// getter, are left out.
+func (g *Generator) logFields(st *StructInfo) []logField {
+	var fields []logField
+	for _, field := range g.valueFields(st) {
+		if !field.HasAccess(AccessRead) || field.HasOption(AccessSecret) || field.Embedded {
+			continue
+		}
+		a := g.newAccessor(st, field.StructFieldInfo)
+		fields = append(fields, logField{Key: field.Name, Getter: a.Getter, typ: field.typ, atomic: field.HasOption(AccessAtomic)})
+	}
+	return fields
+}
+
+// slogAttrs maps the predeclared types to the slog function making an
+// attribute of their values and the type it takes, if different.
+var slogAttrs = map[types.BasicKind][2]string{
+	types.String:  {"String"},
+	types.Bool:    {"Bool"},
+	types.Int:     {"Int"},
+	types.Int8:    {"Int64", "int64"},
+	types.Int16:   {"Int64", "int64"},
+	types.Int32:   {"Int64", "int64"},
+	types.Int64:   {"Int64"},
+	types.Uint:    {"Uint64", "uint64"},
+	types.Uint8:   {"Uint64", "uint64"},
+	types.Uint16:  {"Uint64", "uint64"},
+	types.Uint32:  {"Uint64", "uint64"},
+	types.Uint64:  {"Uint64"},
+	types.Float32: {"Float64", "float64"},
+	types.Float64: {"Float64"},
+}
+
+// genLogValue produces the LogValue method of the struct type, making it
+// a slog.LogValuer logged as a group of its readable fields, read through
+// the getters. Secret fields are left out. Predeclared types, time.Time
+// and time.Duration get attributes of their kind, other types slog.Any.
+func (g *Generator) genLogValue(st *StructInfo, receiver string) string {
+	g.addImport(st.Name, Import{Path: "log/slog"})
+	w := &codeWriter{}
+	w.line("// LogValue logs %s as a group of its readable fields, but the secret ones.", receiver)
+	w.line("func (%s *%s) LogValue() slog.Value {", receiver, st.TypeName())
+	w.indent++
+	w.line("if %s == nil {", receiver)
+	w.line("\treturn slog.AnyValue(nil)")
+	w.line("}")
+	fields := g.logFields(st)
+	if len(fields) == 0 {
+		w.line("return slog.GroupValue()")
+		w.indent--
+		w.line("}")
+		return w.String()
+	}
+	w.line("return slog.GroupValue(")
+	w.indent++
+	for _, field := range fields {
+		value := fmt.Sprintf("%s.%s()", receiver, field.Getter)
+		fn := "Any"
+		switch {
+		case field.atomic:
+		case isNamed(field.typ, "time", "Time"):
+			fn = "Time"
+		case isNamed(field.typ, "time", "Duration"):
+			fn = "Duration"
+		default:
+			if basic, ok := field.typ.(*types.Basic); ok {
+				if attr, ok := slogAttrs[basic.Kind()]; ok {
+					fn = attr[0]
+					if attr[1] != "" {
+						value = attr[1] + "(" + value + ")"
+					}
+				}
+			}
+		}
+		w.line("slog.%s(%q, %s),", fn, field.Key, value)
+	}
+	w.indent--
+	w.line(")")
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// isNamed reports whether t is the named type name of the package path.
+func isNamed(t types.Type, path, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == path && named.Obj().Name() == name
+}
+
+// zapAdders maps the predeclared types to the zapcore.ObjectEncoder
+// method adding their values.
+var zapAdders = map[types.BasicKind]string{
+	types.String:     "AddString",
+	types.Bool:       "AddBool",
+	types.Int:        "AddInt",
+	types.Int8:       "AddInt8",
+	types.Int16:      "AddInt16",
+	types.Int32:      "AddInt32",
+	types.Int64:      "AddInt64",
+	types.Uint:       "AddUint",
+	types.Uint8:      "AddUint8",
+	types.Uint16:     "AddUint16",
+	types.Uint32:     "AddUint32",
+	types.Uint64:     "AddUint64",
+	types.Uintptr:    "AddUintptr",
+	types.Float32:    "AddFloat32",
+	types.Float64:    "AddFloat64",
+	types.Complex64:  "AddComplex64",
+	types.Complex128: "AddComplex128",
+}
+
+// genMarshalLogObject produces the MarshalLogObject method of the struct
+// type, making it a zapcore.ObjectMarshaler logged as an object of its
+// readable fields, read through the getters. Secret fields are left out.
+// Values of a predeclared type, of a defined type over one, of time.Time
+// and time.Duration get the Add method of their kind, a defined type
+// through its String method if it has one. ObjectMarshalers, including
+// the types of the package generated with -zap, are added as objects and
+// the other types with AddReflected.
+func (g *Generator) genMarshalLogObject(st *StructInfo, receiver string) string {
+	g.addImport(st.Name, Import{Path: "go.uber.org/zap/zapcore"})
+	w := &codeWriter{}
+	w.line("// MarshalLogObject logs %s as an object of its readable fields, but the", receiver)
+	w.line("// secret ones.")
+	w.line("func (%s *%s) MarshalLogObject(enc zapcore.ObjectEncoder) error {", receiver, st.TypeName())
+	w.indent++
+	w.line("if %s == nil {", receiver)
+	w.line("\treturn nil")
+	w.line("}")
+	for _, field := range g.logFields(st) {
+		value := fmt.Sprintf("%s.%s()", receiver, field.Getter)
+		if field.atomic {
+			g.addReflected(w, field.Key, value)
+			continue
+		}
+		switch t := field.typ; {
+		case isNamed(t, "time", "Time"):
+			w.line("enc.AddTime(%q, %s)", field.Key, value)
+		case isNamed(t, "time", "Duration"):
+			w.line("enc.AddDuration(%q, %s)", field.Key, value)
+		case g.objectMarshaler(t):
+			w.line("if err := enc.AddObject(%q, %s); err != nil {", field.Key, value)
+			w.line("\treturn err")
+			w.line("}")
+		default:
+			basic, ok := t.Underlying().(*types.Basic)
+			if !ok || zapAdders[basic.Kind()] == "" {
+				g.addReflected(w, field.Key, value)
+				break
+			}
+			if _, isBasic := t.(*types.Basic); !isBasic {
+				if hasStringMethod(t) {
+					w.line("enc.AddString(%q, %s.String())", field.Key, value)
+					break
+				}
+				value = basic.Name() + "(" + value + ")"
+			}
+			w.line("enc.%s(%q, %s)", zapAdders[basic.Kind()], field.Key, value)
+		}
+	}
+	w.line("return nil")
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// addReflected writes the statements adding value with AddReflected.
+func (g *Generator) addReflected(w *codeWriter, key, value string) {
+	w.line("if err := enc.AddReflected(%q, %s); err != nil {", key, value)
+	w.line("\treturn err")
+	w.line("}")
+}
+
+// objectMarshaler reports whether values of type t are
+// zapcore.ObjectMarshalers: pointers to the types of the package whose
+// MarshalLogObject method is generated and the types with a
+// MarshalLogObject method declared outside the files generated in the
+// package.
+func (g *Generator) objectMarshaler(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		if named, ok := types.Unalias(p.Elem()).(*types.Named); ok && named.Obj().Pkg() == g.pkg.types && g.generating[named.Obj().Name()] {
+			return true
+		}
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, false, g.pkg.types, "MarshalLogObject")
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == g.pkg.types && g.generatedFile(fn.Pos()) != "" {
+		return false
+	}
+	sig := fn.Signature()
+	return sig.Params().Len() == 1 && sig.Results().Len() == 1
+}
+
+// hasStringMethod reports whether values of type t have a String() string
+// method.
+func hasStringMethod(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "String")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Signature()
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && isString(sig.Results().At(0).Type())
+}
@@ -0,0 +1,56 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+// embeddedSrc declares a struct embedding another through a pointer.
+const embeddedSrc = `package p
+
+type Base struct {
+	ID int
+}
+
+type User struct {
+	*Base
+	Name string
+}
+`
+
+func TestLogValueEmbedded(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": embeddedSrc})
+	cfg := DefaultConfig()
+	cfg.Embedded, cfg.Slog = true, true
+	src := generate(t, cfg, dir, "User")
+	runTests(t, dir, src, `package p
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	var b bytes.Buffer
+	slog.New(slog.NewTextHandler(&b, nil)).Info("m", "u", &User{Base: &Base{ID: 1}, Name: "a"})
+	if !strings.Contains(b.String(), "u.Name=a") {
+		t.Errorf("logged %s", b.String())
+	}
+}
+`)
+}
+
+// TestMarshalLogObjectEmbedded checks the output only: the test modules
+// don't depend on zap.
+func TestMarshalLogObjectEmbedded(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": embeddedSrc})
+	cfg := DefaultConfig()
+	cfg.Embedded, cfg.Zap = true, true
+	src := generate(t, cfg, dir, "User")
+	contains(t, src, `enc.AddString("Name", u.GetName())`)
+	if strings.Contains(src, "GetBase") {
+		t.Errorf("MarshalLogObject reads the embedded Base through a getter:\n%s", src)
+	}
+}
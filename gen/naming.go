@@ -0,0 +1,188 @@
+package gen
+
+import (
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitWords splits a Go identifier into its words: "HTTPServerID" gives
+// HTTP, Server, ID and "user_name" gives user, name.
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && !boundary(runes, i) {
+			continue
+		}
+		word := strings.Trim(string(runes[start:i]), "_-")
+		if word != "" {
+			words = append(words, word)
+		}
+		start = i
+	}
+	return words
+}
+
+// boundary reports whether a new word starts at runes[i].
+func boundary(runes []rune, i int) bool {
+	prev, cur := runes[i-1], runes[i]
+	switch {
+	case cur == '_' || cur == '-':
+		return true
+	case prev == '_' || prev == '-':
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsDigit(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsUpper(prev) && unicode.IsUpper(cur):
+		// The last capital of an initialism starts the next word
+		// (HTTPServer), unless it is a plural (URLs).
+		if i+1 >= len(runes) || !unicode.IsLower(runes[i+1]) {
+			return false
+		}
+		plural := runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+		return !plural
+	}
+	return false
+}
+
+// snakeCase converts an identifier to snake_case.
+func snakeCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// camelCase converts an identifier to camelCase: "user_name" and
+// "UserName" give userName.
+func camelCase(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			word = capitalize(word)
+		}
+		words[i] = word
+	}
+	return strings.Join(words, "")
+}
+
+// kebabCase converts an identifier to kebab-case.
+func kebabCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+// commonInitialisms are the initialisms written in capitals in method
+// names, following golint.
+var commonInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+// initialisms replaces the words of name that are initialisms by their
+// spelling in set, keyed by the word in capitals: "apiKey" gives "APIKey",
+// "userId" gives "userID" and "Urls" gives "URLs". Other words and
+// separators are kept as they are.
+func initialisms(name string, set map[string]string) string {
+	var out strings.Builder
+	pos := 0
+	for _, word := range splitWords(name) {
+		i := pos + strings.Index(name[pos:], word)
+		out.WriteString(name[pos:i])
+		pos = i + len(word)
+		upper := strings.ToUpper(word)
+		if s, ok := set[upper]; ok {
+			word = s
+		} else if s, ok := set[strings.TrimSuffix(upper, "S")]; ok && strings.HasSuffix(word, "s") {
+			word = s + "s"
+		}
+		out.WriteString(word)
+	}
+	out.WriteString(name[pos:])
+	return out.String()
+}
+
+// capitalize makes the first letter of s upper case, so that the name of
+// an unexported field forms exported looking method names: Get + name
+// gives GetName.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// generatedNames are the identifiers the generated methods declare or
+// refer to besides the receiver.
+var generatedNames = []string{
+	"param", "old", "out", "embed", "k", "v", "i", "key", "ok", "values", "err",
+	"field", "fields", "observer", "other", "va", "vb", "typ", "enc", "name", "diff", "patch",
+	"auditLog", "atomic", "errors",
+}
+
+// receiverName returns the receiver name of the methods of st: -receiver
+// when given, or else the lower-cased first letter of the type. When that
+// clashes with a name the methods use, a field, a type parameter or an
+// imported package, the initials of the type's words, the type name
+// starting in lower case and then numbered variants are tried in turn.
+func (g *Generator) receiverName(st *StructInfo) string {
+	if g.receiver != "" {
+		return g.receiver
+	}
+	taken := make(map[string]bool)
+	for _, name := range generatedNames {
+		taken[name] = true
+	}
+	for _, field := range st.Fields {
+		taken[field.Name] = true
+		for _, imp := range field.Imports {
+			if imp.Name != "" {
+				taken[imp.Name] = true
+			} else {
+				taken[guessPackageName(imp.Path)] = true
+			}
+		}
+	}
+	for _, param := range st.TypeParams {
+		taken[param.Name] = true
+	}
+	free := func(name string) bool {
+		return name != "" && !taken[name] && !token.IsKeyword(name) && token.IsIdentifier(name)
+	}
+
+	r, _ := utf8.DecodeRuneInString(st.Name)
+	first := string(unicode.ToLower(r))
+	var initials strings.Builder
+	for _, word := range splitWords(st.Name) {
+		r, _ := utf8.DecodeRuneInString(word)
+		initials.WriteRune(unicode.ToLower(r))
+	}
+	for _, name := range []string{first, initials.String(), first + st.Name[len(string(r)):]} {
+		if free(name) {
+			return name
+		}
+	}
+	for n := 1; ; n++ {
+		if name := first + strconv.Itoa(n); free(name) {
+			return name
+		}
+	}
+}
+
+// unexport makes the method name s unexported: GetName gives getName, and
+// an initialism starting it is lower-cased whole, ID giving id and
+// URLPath urlPath.
+func unexport(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return s
+	}
+	if first := words[0]; len(first) > 1 && first == strings.ToUpper(first) {
+		return strings.ToLower(first) + s[len(first):]
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
@@ -0,0 +1,46 @@
+package gen
+
+import (
+	"bytes"
+	"go/types"
+	"text/template"
+)
+
+// AccessObservers marks the map[string][]interface{} field holding the
+// functions registered by the On<Field>Change methods, with -observers.
+const AccessObservers = "observers"
+
+var observerTemplate = template.Must(template.New("observer").Parse(`
+{{with .Deprecated}}{{.}}
+{{end}}func ({{.Receiver}} *{{.Struct}}) On{{.Name}}Change(observer func(old, new {{.Type}})) {
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.Lock()
+	defer {{.Receiver}}.{{.Lock}}.Unlock()
+{{- end}}
+	if {{.Receiver}}.{{.Observers}} == nil {
+		{{.Receiver}}.{{.Observers}} = make(map[string][]interface{})
+	}
+	{{.Receiver}}.{{.Observers}}["{{.Field}}"] = append({{.Receiver}}.{{.Observers}}["{{.Field}}"], observer)
+}`))
+
+// observersField returns the field of st tagged access:"observers", which
+// must be a map[string][]interface{}. It returns an ErrMissingField error
+// when there is none.
+func (g *Generator) observersField(st *StructInfo) (string, error) {
+	return g.mapField(st, AccessObservers, "map[string][]interface{}", "observers", func(elem types.Type) bool {
+		slice, ok := elem.Underlying().(*types.Slice)
+		if !ok {
+			return false
+		}
+		iface, ok := slice.Elem().Underlying().(*types.Interface)
+		return ok && iface.Empty()
+	})
+}
+
+// genObserver produces the On<Field>Change method of a, registering an
+// observer the setter calls with the old and new value of the field.
+func genObserver(a accessor) string {
+	var b bytes.Buffer
+	observerTemplate.Execute(&b, a)
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package gen
+
+import "testing"
+
+func TestAtomicObservers(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+import "sync"
+
+type Counter struct {
+	mu        sync.Mutex
+	n         int64                    ` + "`access:\"r,w,atomic\"`" + `
+	observers map[string][]interface{} ` + "`access:\"observers\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Observers = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter")
+	runTests(t, dir, src, `package p
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSet(t *testing.T) {
+	var c Counter
+	var calls int
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.OnNChange(func(old, new int64) { calls++ })
+		}()
+		go func(i int) {
+			defer wg.Done()
+			c.SetN(int64(i))
+		}(i)
+	}
+	wg.Wait()
+	calls = 0
+	c.SetN(100)
+	if calls != 8 {
+		t.Errorf("SetN called %d observers, want 8", calls)
+	}
+}
+`)
+}
@@ -0,0 +1,89 @@
+package gen
+
+import (
+	"bytes"
+	"text/template"
+)
+
+var optionsTemplate = template.Must(template.New("options").Parse(`
+// {{.Name}}Option configures a {{.Name}} created by New{{.Name}}.
+type {{.Name}}Option{{.TypeParams}} func(*{{.Type}}){{if .ReturnsError}} error{{end}}
+
+// New{{.Name}} returns a new {{.Name}} with the options applied in order
+{{- if .ReturnsError}}, or the
+// error of the first failing one{{end}}.
+func New{{.Name}}{{.TypeParams}}(opts ...{{.Name}}Option{{.TypeArgs}}) {{if .ReturnsError}}(*{{.Type}}, error){{else}}*{{.Type}}{{end}} {
+	v := new({{.Type}})
+	for _, opt := range opts {
+{{- if .ReturnsError}}
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+{{- else}}
+		opt(v)
+{{- end}}
+	}
+	return v{{if .ReturnsError}}, nil{{end}}
+}
+{{range .Fields}}
+// With{{$.Name}}{{.Name}} returns an option setting {{.Field}} through {{if .Immutable}}With{{.Name}}{{else}}{{.Setter}}{{end}}.
+{{- with .Deprecated}}
+//
+{{.}}
+{{- end}}
+func With{{$.Name}}{{.Name}}{{$.TypeParams}}(param {{.Type}}) {{$.Name}}Option{{$.TypeArgs}} {
+	return func(v *{{$.Type}}){{if $.ReturnsError}} error{{end}} {
+{{- if .Immutable}}
+		*v = v.With{{.Name}}(param)
+{{- else if .ReturnsError}}
+		return v.{{.Setter}}(param)
+{{- else}}
+		v.{{.Setter}}(param)
+{{- end}}
+{{- if and $.ReturnsError (not .ReturnsError)}}
+		return nil
+{{- end}}
+	}
+}
+{{end}}`))
+
+// genOptions produces the functional options constructor of the struct
+// type: a <Type>Option type, New<Type> and a With<Type><Field> option for
+// every writable field, set through its accessor so that its checks, hooks
+// and change tracking apply. The type name is part of the option names so
+// that several types of a package can use options side by side. When a
+// setter returns an error, so do the options and New<Type>.
+func (g *Generator) genOptions(st *StructInfo, fields []dynamicField) string {
+	data := struct {
+		Name         string
+		Type         string
+		TypeParams   string
+		TypeArgs     string
+		Fields       []dynamicField
+		ReturnsError bool
+	}{
+		Name:       st.Name,
+		Type:       st.TypeName(),
+		TypeParams: st.TypeParamsDecl(),
+		TypeArgs:   st.TypeArgs(),
+	}
+	byKey := make(map[string]dynamicField)
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+	for _, field := range g.fields(st.Fields) {
+		f, ok := byKey[field.Name]
+		if !ok || !f.Write {
+			continue
+		}
+		if f.Load == "" && holdsLock(g.fieldType(field)) {
+			// A lock can't be passed by value.
+			continue
+		}
+		data.Fields = append(data.Fields, f)
+		data.ReturnsError = data.ReturnsError || f.ReturnsError
+	}
+	var buf bytes.Buffer
+	optionsTemplate.Execute(&buf, data)
+	return buf.String()
+}
@@ -0,0 +1,65 @@
+package gen
+
+import "testing"
+
+func TestOptionsSetters(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	name    string          ` + "`access:\"r,w\"`" + `
+	age     int             ` + "`access:\"r,w,min=0,max=150\"`" + `
+	changes map[string]bool ` + "`access:\"changes\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Options = true
+	cfg.TrackChanges = true
+	cfg.Validate = ValidateError
+	src := generate(t, cfg, dir, "User")
+	runTests(t, dir, src, `package p
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewUser(t *testing.T) {
+	if _, err := NewUser(WithUserName("a"), WithUserAge(200)); err == nil {
+		t.Error("NewUser with an age over the maximum succeeded")
+	}
+	u, err := NewUser(WithUserName("a"), WithUserAge(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetName() != "a" || u.GetAge() != 3 {
+		t.Errorf("NewUser returned %q %d", u.GetName(), u.GetAge())
+	}
+	if got, want := u.ChangedFields(), []string{"age", "name"}; !slices.Equal(got, want) {
+		t.Errorf("ChangedFields() = %v, want %v", got, want)
+	}
+}
+`)
+}
+
+func TestOptionsClamp(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	age int ` + "`access:\"r,w,min=0,max=150\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Options = true
+	cfg.Validate = ValidateClamp
+	src := generate(t, cfg, dir, "User")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestNewUser(t *testing.T) {
+	if u := NewUser(WithUserAge(200)); u.GetAge() != 150 {
+		t.Errorf("age = %d, want 150", u.GetAge())
+	}
+}
+`)
+}
@@ -0,0 +1,115 @@
+package gen
+
+import (
+	"bytes"
+	"go/types"
+	"sync"
+	"text/template"
+)
+
+// outputs are the files seen by Write, shared by a Generator and its forks.
+type outputs struct {
+	mu        sync.Mutex
+	stale     []string // files found out of date with Check
+	written   []string // files written
+	unchanged []string // files left alone as they are up to date
+	// byPackage are the files output for each package, "-" for the
+	// standard output.
+	byPackage map[*Package][]string
+}
+
+// record adds the file name output for pkg, and adds it to list unless
+// list is nil.
+func (o *outputs) record(pkg *Package, name string, list *[]string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if list != nil {
+		*list = append(*list, name)
+	}
+	if o.byPackage == nil {
+		o.byPackage = make(map[*Package][]string)
+	}
+	o.byPackage[pkg] = append(o.byPackage[pkg], name)
+}
+
+// Fork returns a Generator with the configuration and the loaded packages
+// of g but state of its own, to generate on another goroutine. The fork
+// starts on the current package of g and shares the struct types parsed
+// for it; Stale and Written report the files of g and all its forks.
+// Fork reads g unguarded: it must not run while g is generating.
+func (g *Generator) Fork() *Generator {
+	f := *g
+	f.buf = make(map[string]*bytes.Buffer)
+	f.imports = nil
+	f.plans = nil
+	f.knownTypes = make(map[string]types.Type)
+	f.rendering = ""
+	// The template functions refer to the generator executing them.
+	funcs := f.templateFuncs()
+	f.templates = make(map[string]*template.Template, len(g.templates))
+	for name, t := range g.templates {
+		f.templates[name] = template.Must(t.Clone()).Funcs(funcs)
+	}
+	return &f
+}
+
+// GenerateTypes runs Generate for the types of the current package on up
+// to workers goroutines and returns the errors by type name. The output
+// is the same as that of generating the types one after the other.
+func (g *Generator) GenerateTypes(typeNames []string, workers int) map[string]error {
+	// Parse the struct types once for all the forks, and fork before the
+	// workers start: adopt changes g as they run.
+	g.loadStructs()
+	forks := make([]*Generator, len(typeNames))
+	for i := range forks {
+		forks[i] = g.Fork()
+	}
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < max(1, min(workers, len(typeNames))); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				typeName, f := typeNames[i], forks[i]
+				err := f.Generate(typeName)
+				mu.Lock()
+				if err != nil {
+					errs[typeName] = err
+				} else {
+					g.adopt(f, typeName)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range typeNames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// adopt takes over the output generated by the fork f for the type.
+func (g *Generator) adopt(f *Generator, typeName string) {
+	if plan, ok := f.plans[typeName]; ok {
+		if g.plans == nil {
+			g.plans = make(map[string]Plan)
+		}
+		g.plans[typeName] = plan
+	}
+	for _, key := range []string{typeName, testKey(typeName)} {
+		if buf, ok := f.buf[key]; ok {
+			g.buf[key] = buf
+		}
+		if imports, ok := f.imports[key]; ok {
+			if g.imports == nil {
+				g.imports = make(map[string]map[string]string)
+			}
+			g.imports[key] = imports
+		}
+	}
+}
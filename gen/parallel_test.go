@@ -0,0 +1,43 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestGenerateTypes generates types on several goroutines, which go test
+// -race checks, and compares the output with that of generating them one
+// after the other.
+func TestGenerateTypes(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("package p\n\nimport \"time\"\n")
+	var names []string
+	for i := 0; i < 16; i++ {
+		name := fmt.Sprintf("T%d", i)
+		names = append(names, name)
+		fmt.Fprintf(&src, "\ntype %s struct {\n\tName string `access:\"r,w,required\"`\n\tAt   time.Time\n\tNext *%s\n}\n", name, name)
+	}
+	dir := testPackage(t, map[string]string{"p.go": src.String()})
+	cfg := DefaultConfig()
+	cfg.Equal, cfg.Builder = true, true
+	want := generate(t, cfg, dir, names...)
+
+	g := load(t, cfg, dir)
+	g.SetTypes(names)
+	for name, err := range g.GenerateTypes(names, 8) {
+		t.Errorf("%s: %v", name, err)
+	}
+	got, err := g.Bytes(names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("output differs from that of generating in order:\n%s", UnifiedDiff("in order", "parallel", []byte(want), got))
+	}
+	for _, name := range names {
+		if _, ok := g.Plan(name); !ok {
+			t.Errorf("no plan recorded for %s", name)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package gen
+
+// Plan describes the output of Generate for a struct type: its fields
+// with their access modes and the methods generated for each, so that the
+// tag configuration can be checked without writing anything.
+type Plan struct {
+	Type    string      `json:"type"`
+	Fields  []FieldPlan `json:"fields"`
+	Methods []string    `json:"methods,omitempty"` // methods of the type as a whole, such as Equal
+}
+
+// FieldPlan is a field of a Plan with the methods generated for it, in
+// the order they are generated.
+type FieldPlan struct {
+	StructFieldInfo
+	Methods []string `json:"methods,omitempty"`
+}
+
+// Plan returns the plan of the type generated by Generate, and false if
+// it has not been generated.
+func (g *Generator) Plan(typeName string) (Plan, bool) {
+	plan, ok := g.plans[typeName]
+	return plan, ok
+}
+
+// recordPlan records the plan of st, generated for the type typeName,
+// once its methods are generated. typeName differs from st.Name for an
+// alias such as type Account = account, whose plan is found under
+// Account.
+func (g *Generator) recordPlan(typeName string, st *StructInfo, methods *methodSet) {
+	plan := Plan{Type: typeName}
+	byField := make(map[string][]string)
+	for _, method := range methods.order {
+		if field := methods.methods[method]; field != "" {
+			byField[field] = append(byField[field], method)
+		} else {
+			plan.Methods = append(plan.Methods, method)
+		}
+	}
+	for _, field := range g.fields(st.Fields) {
+		plan.Fields = append(plan.Fields, FieldPlan{field, byField[field.Name]})
+	}
+	if g.plans == nil {
+		g.plans = make(map[string]Plan)
+	}
+	g.plans[typeName] = plan
+}
@@ -0,0 +1,26 @@
+package gen
+
+import "testing"
+
+func TestPlanAlias(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type account struct {
+	Name string
+}
+
+type Account = account
+`})
+	g := load(t, DefaultConfig(), dir)
+	g.SetTypes([]string{"Account"})
+	if err := g.Generate("Account"); err != nil {
+		t.Fatal(err)
+	}
+	plan, ok := g.Plan("Account")
+	if !ok {
+		t.Fatal("no plan recorded for Account")
+	}
+	if plan.Type != "Account" || len(plan.Fields) != 1 || len(plan.Fields[0].Methods) != 2 {
+		t.Errorf("plan of Account = %+v", plan)
+	}
+}
@@ -0,0 +1,95 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"text/template"
+)
+
+var safeTemplate = template.Must(template.New("safe").Parse(`
+// Safe{{.Name}} guards a {{.Name}} with a sync.RWMutex, leaving {{.Name}} itself a
+// plain struct: its accessors are called with the lock held.
+type Safe{{.Name}}{{.TypeParams}} struct {
+	mu sync.RWMutex
+	v  {{.Struct}}
+}
+
+// NewSafe{{.Name}} returns a Safe{{.Name}} holding v.
+func NewSafe{{.Name}}{{.TypeParams}}(v {{.Struct}}) *Safe{{.Name}}{{.TypeArgs}} {
+	return &Safe{{.Name}}{{.TypeArgs}}{v: v}
+}
+
+// Load returns a copy of the {{.Name}}.
+func (s *Safe{{.Name}}{{.TypeArgs}}) Load() {{.Struct}} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v
+}
+
+// Update calls f with the {{.Name}}, locked for writing.
+func (s *Safe{{.Name}}{{.TypeArgs}}) Update(f func(*{{.Struct}})) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&s.v)
+}
+{{range .Fields}}{{if .Read}}
+// {{.Getter}} calls {{$.Name}}.{{.Getter}} under the read lock.
+{{- with .Deprecated}}
+//
+{{.}}
+{{- end}}
+func (s *Safe{{$.Name}}{{$.TypeArgs}}) {{.Getter}}() {{.Type}} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.v.{{.Getter}}()
+}
+{{end}}{{if .Write}}
+{{- if .Immutable}}
+// {{.Setter}} replaces the {{$.Name}} by {{$.Name}}.With{{.Name}} under the write lock.
+{{- else}}
+// {{.Setter}} calls {{$.Name}}.{{.Setter}} under the write lock.
+{{- end}}
+{{- with .Deprecated}}
+//
+{{.}}
+{{- end}}
+func (s *Safe{{$.Name}}{{$.TypeArgs}}) {{.Setter}}(param {{.Type}}){{if .ReturnsError}} error{{end}} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+{{- if .Immutable}}
+	s.v = s.v.With{{.Name}}(param)
+{{- else if .ReturnsError}}
+	return s.v.{{.Setter}}(param)
+{{- else}}
+	s.v.{{.Setter}}(param)
+{{- end}}
+}
+{{end}}{{end}}`))
+
+// genSafe produces Safe<Type>, a wrapper holding a value of the struct type
+// with a sync.RWMutex, and its accessors calling those of the fields under
+// the lock. Safe<Type> copies the value in and out, so types holding a
+// mutex or a sync/atomic value, which must not be copied, are rejected;
+// -threadsafe locks those.
+func (g *Generator) genSafe(st *StructInfo, fields []dynamicField) (string, error) {
+	if u, ok := g.pkg.types.Scope().Lookup(st.Name).Type().Underlying().(*types.Struct); ok {
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); holdsLock(f.Type()) {
+				return "", &Error{Kind: ErrUnsupported, Type: st.Name, Field: f.Name(),
+					Err: fmt.Errorf("Safe%s copies %s, which holds a lock or atomic value; use -threadsafe", st.Name, st.Name)}
+			}
+		}
+	}
+	g.addImport(st.Name, Import{Path: "sync"})
+	data := dynamicMethods{
+		Struct:     st.TypeName(),
+		Name:       st.Name,
+		TypeParams: st.TypeParamsDecl(),
+		TypeArgs:   st.TypeArgs(),
+		Fields:     fields,
+	}
+	var buf bytes.Buffer
+	safeTemplate.Execute(&buf, data)
+	return buf.String(), nil
+}
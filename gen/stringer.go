@@ -0,0 +1,178 @@
+package gen
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// AccessSecret hides the value of a field from the String, LogValue and
+// MarshalLogObject methods, as in access:"r,secret".
+const AccessSecret = "secret"
+
+// redacted stands for the value of a secret field.
+const redacted = "***"
+
+// valueField is a field making up the value of a struct for the methods
+// comparing or printing it.
+type valueField struct {
+	StructFieldInfo
+	typ types.Type
+}
+
+// valueFields returns the fields of st making up its value: all but those
+// tagged access:"-", blank fields, mutexes and the fields recording the
+// changes and observers.
+func (g *Generator) valueFields(st *StructInfo) []valueField {
+	var fields []valueField
+	for _, field := range st.Fields {
+		if field.Skip || field.Name == "_" || field.HasOption(AccessChanges) || field.HasOption(AccessObservers) {
+			continue
+		}
+		t := g.fieldType(field)
+		if t == nil {
+			continue
+		}
+		if _, ok := g.mutexType(t); ok {
+			continue
+		}
+		fields = append(fields, valueField{field, t})
+	}
+	return fields
+}
+
+// load returns the expression reading the field of x and the type of its
+// value. The fields of the sync/atomic types and those tagged atomic are
+// loaded atomically, as their accessors do, rather than read as structs
+// racing with their setters.
+func (g *Generator) load(st *StructInfo, x string, field valueField) (string, types.Type) {
+	t := field.typ
+	if _, ok := atomicType(t); ok {
+		if t = g.loadType(t); t == nil {
+			t = field.typ
+		}
+	}
+	if field.HasOption(AccessAtomic) {
+		a := g.newAccessor(st, field.StructFieldInfo)
+		a.Receiver = x
+		if err := g.atomicAccessor(st.Name, &a, field.StructFieldInfo); err == nil {
+			return a.Load, t
+		}
+	}
+	if t != field.typ {
+		return x + "." + field.Name + ".Load()", t
+	}
+	return x + "." + field.Name, t
+}
+
+// genString produces the String method of the struct type, printing the
+// fields as in User{Name: "alice", Password: ***}: strings quoted, secret
+// fields as ***, function and channel fields as their type, the others
+// with %v. Atomic fields are loaded, and the fields read under the lock of
+// the thread-safe accessors.
+func (g *Generator) genString(st *StructInfo, receiver string) string {
+	var format strings.Builder
+	var args []string
+	format.WriteString(st.Name + "{")
+	for i, field := range g.valueFields(st) {
+		if i > 0 {
+			format.WriteString(", ")
+		}
+		format.WriteString(field.Name + ": ")
+		switch {
+		case field.HasOption(AccessSecret):
+			format.WriteString(redacted)
+			continue
+		case isFuncOrChan(field.typ):
+			format.WriteString(strings.ReplaceAll(field.Type, "%", "%%"))
+			continue
+		}
+		value, t := g.load(st, receiver, field)
+		if isString(t) {
+			format.WriteString("%q")
+		} else {
+			format.WriteString("%v")
+		}
+		args = append(args, value)
+	}
+	format.WriteString("}")
+
+	w := &codeWriter{}
+	w.line("// String returns the fields of %s, hiding those tagged %s.", receiver, AccessSecret)
+	w.line("func (%s *%s) String() string {", receiver, st.TypeName())
+	w.indent++
+	w.line("if %s == nil {", receiver)
+	w.line("\treturn \"<nil>\"")
+	w.line("}")
+	if mu := g.structLock(st); mu != nil && len(args) > 0 {
+		mu.readLock(w, receiver)
+	}
+	if len(args) == 0 {
+		w.line("return %q", format.String())
+	} else {
+		g.addImport(st.Name, Import{Path: "fmt"})
+		w.line("return fmt.Sprintf(%q, %s)", format.String(), strings.Join(args, ", "))
+	}
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// genGoString produces the GoString method of the struct type, printing
+// Go syntax that builds a copy of the value for %#v: a composite literal
+// of the exported fields, wrapped in a function setting the unexported
+// ones through their setters or With methods when there are any.
+// Unexported fields without one, secret fields and function and channel
+// fields, which have no Go syntax, are left out, as are the fields of the
+// sync/atomic types not tagged atomic: their loaded value can only be set
+// through the setter.
+func (g *Generator) genGoString(st *StructInfo, receiver string) string {
+	var literal, calls []string
+	var fieldArgs, callArgs []string
+	for _, field := range g.valueFields(st) {
+		value, _ := g.load(st, receiver, field)
+		_, isAtomic := atomicType(field.typ)
+		switch {
+		case field.HasOption(AccessSecret):
+		case isFuncOrChan(field.typ):
+		case isAtomic && !field.HasOption(AccessAtomic):
+		case token.IsExported(field.Name) && !isAtomic:
+			literal = append(literal, field.Name+":%#v")
+			fieldArgs = append(fieldArgs, value)
+		case field.HasAccess(AccessWrite):
+			a := g.newAccessor(st, field.StructFieldInfo)
+			if a.Immutable {
+				calls = append(calls, "*v = v.With"+a.Name+"(%#v); ")
+			} else {
+				calls = append(calls, "v."+a.Setter+"(%#v); ")
+			}
+			callArgs = append(callArgs, value)
+		}
+	}
+	format := "&%s{" + strings.Join(literal, ", ") + "}"
+	args := append([]string{"typ"}, fieldArgs...)
+	if len(calls) > 0 {
+		format = "func() *%s { v := " + format + "; " + strings.Join(calls, "") + "return v }()"
+		args = append([]string{"typ"}, args...)
+		args = append(args, callArgs...)
+	}
+
+	g.addImport(st.Name, Import{Path: "fmt"})
+	w := &codeWriter{}
+	w.line("// GoString returns Go syntax building a copy of %s, setting the", receiver)
+	w.line("// unexported fields through the accessors. Secret, function and channel")
+	w.line("// fields are left out.")
+	w.line("func (%s *%s) GoString() string {", receiver, st.TypeName())
+	w.indent++
+	w.line("if %s == nil {", receiver)
+	w.line("\treturn fmt.Sprintf(\"(%%T)(nil)\", %s)", receiver)
+	w.line("}")
+	if mu := g.structLock(st); mu != nil && len(args) > 1 {
+		mu.readLock(w, receiver)
+	}
+	w.line("typ := fmt.Sprintf(\"%%T\", %s)[1:]", receiver)
+	w.line("return fmt.Sprintf(%q, %s)", format, strings.Join(args, ", "))
+	w.indent--
+	w.line("}")
+	return w.String()
+}
@@ -0,0 +1,131 @@
+package gen
+
+import "testing"
+
+func TestStringFuncFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Handler func(string) error
+
+type Server struct {
+	Name    string
+	OnClose func()
+	handle  Handler
+	events  chan int
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Stringer = true
+	src := generate(t, cfg, dir, "Server")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestString(t *testing.T) {
+	s := &Server{Name: "a", OnClose: func() {}, events: make(chan int)}
+	want := "Server{Name: \"a\", OnClose: func(), handle: Handler, events: chan int}"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+`)
+}
+
+func TestGoStringFuncFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Server struct {
+	Name    string
+	OnClose func()
+	events  chan int ` + "`access:\"r,w\"`" + `
+	port    int      ` + "`access:\"r,w\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.GoStringer = true
+	src := generate(t, cfg, dir, "Server")
+	runTests(t, dir, src, `package p
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGoString(t *testing.T) {
+	s := &Server{Name: "a", OnClose: func() {}, events: make(chan int), port: 80}
+	want := "func() *p.Server { v := &p.Server{Name:\"a\"}; v.SetPort(80); return v }()"
+	if got := fmt.Sprintf("%#v", s); got != want {
+		t.Errorf("%%#v = %s, want %s", got, want)
+	}
+}
+`)
+}
+
+// atomicSrc declares a thread-safe type with atomic fields, which the
+// whole-type methods must load rather than copy.
+const atomicSrc = `package p
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type Counter struct {
+	mu    sync.RWMutex
+	Name  string
+	hits  atomic.Int64 ` + "`access:\"r,w,atomic\"`" + `
+	total int64 ` + "`access:\"r,w,atomic\"`" + `
+	label string ` + "`access:\"r,w\"`" + `
+}
+`
+
+func TestStringAtomicFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": atomicSrc})
+	cfg := DefaultConfig()
+	cfg.Stringer = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter")
+	contains(t, src, "c.mu.RLock()")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestString(t *testing.T) {
+	c := &Counter{Name: "a"}
+	c.SetHits(2)
+	c.SetTotal(3)
+	c.SetLabel("b")
+	want := "Counter{Name: \"a\", hits: 2, total: 3, label: \"b\"}"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+`)
+}
+
+func TestGoStringAtomicFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": atomicSrc})
+	cfg := DefaultConfig()
+	cfg.GoStringer = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter")
+	contains(t, src, "c.mu.RLock()")
+	runTests(t, dir, src, `package p
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGoString(t *testing.T) {
+	c := &Counter{Name: "a"}
+	c.SetHits(2)
+	c.SetTotal(3)
+	c.SetLabel("b")
+	want := "func() *p.Counter { v := &p.Counter{Name:\"a\"}; v.SetHits(2); v.SetTotal(3); v.SetLabel(\"b\"); return v }()"
+	if got := fmt.Sprintf("%#v", c); got != want {
+		t.Errorf("%%#v = %s, want %s", got, want)
+	}
+}
+`)
+}
@@ -0,0 +1,335 @@
+package gen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// accessorTemplates are the built-in templates of the accessor methods,
+// executed with an accessor. Each can be replaced by the file of the same
+// name in the -template-dir directory.
+var accessorTemplates = map[string]string{
+	"getter.tmpl":    getterTemplate,
+	"getter_ok.tmpl": getterOkTemplate,
+	"setter.tmpl":    setterTemplate,
+	"wither.tmpl":    witherTemplate,
+}
+
+// parseTemplates parses the accessor templates with the functions funcs,
+// taking those found in dir, if set, in place of the built-in ones.
+func parseTemplates(dir string, funcs template.FuncMap) (map[string]*template.Template, error) {
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if _, ok := accessorTemplates[name]; !ok && strings.HasSuffix(name, ".tmpl") {
+				names := make([]string, 0, len(accessorTemplates))
+				for name := range accessorTemplates {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				return nil, fmt.Errorf("%s: unknown template, want one of %s", filepath.Join(dir, name), strings.Join(names, ", "))
+			}
+		}
+	}
+	templates := make(map[string]*template.Template)
+	for name, text := range accessorTemplates {
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err == nil {
+				text = string(data)
+			} else if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		t, err := template.New(name).Funcs(funcs).Parse(checksTemplate)
+		if err == nil {
+			t, err = t.Parse(changedTemplate)
+		}
+		if err == nil {
+			t, err = t.Parse(text)
+		}
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
+
+// templateFuncs returns the functions available to the accessor templates.
+// The type functions take a type as written in the output, such as .Type,
+// and see through defined types: isSlice is true for type IDs []int.
+func (g *Generator) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"camel": camelCase,
+		"snake": snakeCase,
+		"kebab": kebabCase,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"zeroValue": func(typ string) (string, error) {
+			t, err := g.lookupType(typ)
+			if err != nil {
+				return "", err
+			}
+			return zeroOf(t, typ), nil
+		},
+		"isPointer": g.isKind(func(t types.Type) bool { _, ok := t.(*types.Pointer); return ok }),
+		"isSlice":   g.isKind(func(t types.Type) bool { _, ok := t.(*types.Slice); return ok }),
+		"isMap":     g.isKind(func(t types.Type) bool { _, ok := t.(*types.Map); return ok }),
+		"elemType":  g.elemType,
+		"comment":   wrapComment,
+	}
+}
+
+// lookupType returns the type written typ in the output, either the type
+// of an accessor or one that is valid in the package scope, like int or
+// a type of the package.
+func (g *Generator) lookupType(typ string) (types.Type, error) {
+	if t, ok := g.knownTypes[typ]; ok {
+		return t, nil
+	}
+	tv, err := types.Eval(token.NewFileSet(), g.pkg.types, token.NoPos, typ)
+	if err != nil {
+		return nil, err
+	}
+	if !tv.IsType() {
+		return nil, fmt.Errorf("%s is not a type", typ)
+	}
+	return tv.Type, nil
+}
+
+// isKind returns a template function reporting whether the underlying
+// type of a type satisfies is.
+func (g *Generator) isKind(is func(types.Type) bool) func(string) (bool, error) {
+	return func(typ string) (bool, error) {
+		t, err := g.lookupType(typ)
+		if err != nil {
+			return false, err
+		}
+		return is(t.Underlying()), nil
+	}
+}
+
+// elemType returns the element type of a pointer, slice, array, map or
+// channel type.
+func (g *Generator) elemType(typ string) (string, error) {
+	t, err := g.lookupType(typ)
+	if err != nil {
+		return "", err
+	}
+	var elem types.Type
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		elem = u.Elem()
+	case *types.Slice:
+		elem = u.Elem()
+	case *types.Array:
+		elem = u.Elem()
+	case *types.Map:
+		elem = u.Elem()
+	case *types.Chan:
+		elem = u.Elem()
+	default:
+		return "", fmt.Errorf("%s has no element type", typ)
+	}
+	s := g.typeString(g.rendering, elem)
+	g.knownTypes[s] = elem
+	return s, nil
+}
+
+// wrapComment formats text as // comment lines at most width columns wide,
+// breaking between words.
+func wrapComment(width int, text string) string {
+	var lines []string
+	line := "//"
+	for _, word := range strings.Fields(text) {
+		if line != "//" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = "//"
+		}
+		line += " " + word
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// render executes the accessor template name for the field of a.
+func (g *Generator) render(name string, a accessor) (string, error) {
+	var b strings.Builder
+	if err := g.templates[name].Execute(&b, a); err != nil {
+		return "", &Error{Kind: ErrTemplate, Type: a.Struct, Field: a.Field, Err: err}
+	}
+	return b.String(), nil
+}
+
+// changedTemplate records the field in the changes field of the struct,
+// after a setter or helper method modified it.
+const changedTemplate = `{{define "changed"}}
+{{- if .Changes}}
+	if {{.Receiver}}.{{.Changes}} == nil {
+		{{.Receiver}}.{{.Changes}} = make(map[string]bool)
+	}
+	{{.Receiver}}.{{.Changes}}["{{.Field}}"] = true
+{{- end}}
+{{- end}}`
+
+// checksTemplate enforces the constraints of the field on param at the
+// start of a setter or With method.
+const checksTemplate = `{{define "checks"}}
+{{- range .Checks}}
+	if {{.Cond}} {
+{{- if eq $.Validate "clamp"}}
+{{- if .Clamp}}
+		param = {{.Clamp}}
+{{- else}}
+		return{{if or $.Immutable $.Chain}} {{$.Receiver}}{{else if $.ReturnsError}} nil{{end}}
+{{- end}}
+{{- else if eq $.Validate "error"}}
+		return errors.New({{printf "%q" .Message}})
+{{- else}}
+		panic({{printf "%q" .Message}})
+{{- end}}
+	}
+{{- end}}
+{{- end}}`
+
+const setterTemplate = `func ({{.Receiver}} *{{.Struct}}) {{.Setter}}(param {{.Type}}){{if .Chain}} *{{.Struct}}{{else if .ReturnsError}} error{{end}} {
+{{- if .SkipZero}}
+	if {{.IsZero "param"}} {
+		return{{if .Chain}} {{.Receiver}}{{else if .ReturnsError}} nil{{end}}
+	}
+{{- end}}
+{{- template "checks" .}}
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.Lock()
+	defer {{.Receiver}}.{{.Lock}}.Unlock()
+{{- end}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		{{.Receiver}}.{{.Embed}} = &{{.EmbedType}}{}
+	}
+{{- end}}
+{{- if or .Audit .BeforeSet .AfterSet .Observers}}
+	old := {{if .Load}}{{.Load}}{{else}}{{.Receiver}}.{{.Field}}{{end}}
+{{- end}}
+{{- if .BeforeSetErr}}
+	if err := {{.Receiver}}.{{.BeforeSet}}(old, param); err != nil {
+		{{if .ReturnsError}}return err{{else}}panic(err){{end}}
+	}
+{{- else if .BeforeSet}}
+	{{.Receiver}}.{{.BeforeSet}}(old, param)
+{{- end}}
+{{- if .Audit}}
+	auditLog("{{.Field}}", old, param)
+{{- end}}
+{{- if .Store}}
+	{{.Store}}
+{{- else}}
+	{{.Receiver}}.{{.Field}} = param
+{{- end}}
+{{- template "changed" .}}
+{{- if .AfterSet}}
+	{{.Receiver}}.{{.AfterSet}}(old, param)
+{{- end}}
+{{- if .Observers}}
+	for _, observer := range {{.Receiver}}.{{.Observers}}["{{.Field}}"] {
+		observer.(func(old, new {{.Type}}))(old, param)
+	}
+{{- end}}
+{{- if .Chain}}
+	return {{.Receiver}}
+{{- else if .ReturnsError}}
+	return nil
+{{- end}}
+}`
+
+// witherTemplate is the immutable counterpart of the setter. The receiver
+// is a copy, so the method assigns to it and returns it; a pointer embedded
+// struct is copied as well so that the original is left untouched.
+const witherTemplate = `func ({{.Receiver}} {{.Struct}}) With{{.Name}}(param {{.Type}}) {{.Struct}} {
+{{- if .SkipZero}}
+	if {{.IsZero "param"}} {
+		return {{.Receiver}}
+	}
+{{- end}}
+{{- template "checks" .}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		{{.Receiver}}.{{.Embed}} = &{{.EmbedType}}{}
+	} else {
+		embed := *{{.Receiver}}.{{.Embed}}
+		{{.Receiver}}.{{.Embed}} = &embed
+	}
+{{- end}}
+{{- if .Audit}}
+	old := {{.Receiver}}.{{.Field}}
+	auditLog("{{.Field}}", old, param)
+{{- end}}
+	{{.Receiver}}.{{.Field}} = param
+	return {{.Receiver}}
+}`
+
+const getterTemplate = `func ({{.Receiver}} {{if not .ValueGetter}}*{{end}}{{.Struct}}) {{.Getter}}() {{.Type}} {
+{{- if .NilSafe}}
+	if {{.Receiver}} == nil {
+		return {{.Zero}}
+	}
+{{- end}}
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.{{if .RLock}}RLock{{else}}Lock{{end}}()
+	defer {{.Receiver}}.{{.Lock}}.{{if .RLock}}RUnlock{{else}}Unlock{{end}}()
+{{- end}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		return {{.Zero}}
+	}
+{{- end}}
+{{- if .Clone}}
+	if {{.Receiver}}.{{.Field}} == nil {
+		return nil
+	}
+	out := make({{.Type}}, len({{.Receiver}}.{{.Field}}))
+{{- if eq .Clone "slice"}}
+	copy(out, {{.Receiver}}.{{.Field}})
+{{- else}}
+	for k, v := range {{.Receiver}}.{{.Field}} {
+		out[k] = v
+	}
+{{- end}}
+	return out
+{{- else}}
+	return {{if .Load}}{{.Load}}{{else}}{{.Receiver}}.{{.Field}}{{end}}
+{{- end}}
+}`
+
+// getterOkTemplate is the getter of a pointer field returning the value
+// pointed to and whether there is one. Type is the element type.
+const getterOkTemplate = `func ({{.Receiver}} {{if not .ValueGetter}}*{{end}}{{.Struct}}) {{.Getter}}Ok() ({{.Type}}, bool) {
+{{- if .NilSafe}}
+	if {{.Receiver}} == nil {
+		return {{.Zero}}, false
+	}
+{{- end}}
+{{- if .Lock}}
+	{{.Receiver}}.{{.Lock}}.{{if .RLock}}RLock{{else}}Lock{{end}}()
+	defer {{.Receiver}}.{{.Lock}}.{{if .RLock}}RUnlock{{else}}Unlock{{end}}()
+{{- end}}
+{{- if .Embed}}
+	if {{.Receiver}}.{{.Embed}} == nil {
+		return {{.Zero}}, false
+	}
+{{- end}}
+	if {{.Receiver}}.{{.Field}} == nil {
+		return {{.Zero}}, false
+	}
+	return *{{.Receiver}}.{{.Field}}, true
+}`
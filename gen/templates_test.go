@@ -0,0 +1,100 @@
+package gen
+
+import "testing"
+
+func TestSkipZero(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Patch struct {
+	name string   ` + "`access:\"r,w,skipzero\"`" + `
+	age  int      ` + "`access:\"r,w,skipzero\"`" + `
+	tags []string ` + "`access:\"r,w,skipzero\"`" + `
+}
+`})
+	src := generate(t, DefaultConfig(), dir, "Patch")
+	contains(t, src, `if param == "" {`, "if param == 0 {", "if param == nil {")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestSkipZero(t *testing.T) {
+	var p Patch
+	p.SetName("ann")
+	p.SetAge(30)
+	p.SetTags([]string{"a"})
+	p.SetName("")
+	p.SetAge(0)
+	p.SetTags(nil)
+	if p.GetName() != "ann" || p.GetAge() != 30 || len(p.GetTags()) != 1 {
+		t.Errorf("zero values were set: %+v", p)
+	}
+	p.SetTags([]string{})
+	if p.GetTags() == nil || len(p.GetTags()) != 0 {
+		t.Errorf("an empty slice is not zero: %+v", p)
+	}
+}
+`)
+}
+
+func TestAudit(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type User struct {
+	Name string
+	Age  int ` + "`access:\"r\"`" + `
+}
+
+var audited []string
+
+func auditLog(field string, old, new interface{}) {
+	audited = append(audited, field+":"+old.(string)+"->"+new.(string))
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Audit = true
+	src := generate(t, cfg, dir, "User")
+	contains(t, src, "old := u.Name\n\tauditLog(\"Name\", old, param)\n\tu.Name = param")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestAudit(t *testing.T) {
+	u := User{Name: "ann"}
+	u.SetName("bob")
+	_ = u.GetName()
+	if len(audited) != 1 || audited[0] != "Name:ann->bob" {
+		t.Errorf("audited %q, want [Name:ann->bob]", audited)
+	}
+}
+`)
+}
+
+func TestClampErrSet(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type V struct {
+	name string ` + "`access:\"r,w,errset,nonempty\"`" + `
+	age  int    ` + "`access:\"r,w,errset,min=0,max=150\"`" + `
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Validate = ValidateClamp
+	src := generate(t, cfg, dir, "V")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestClamp(t *testing.T) {
+	var v V
+	if err := v.SetName("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.SetName(""); err != nil || v.GetName() != "a" {
+		t.Errorf("SetName(\"\") = %v, left %q", err, v.GetName())
+	}
+	if err := v.SetAge(200); err != nil || v.GetAge() != 150 {
+		t.Errorf("SetAge(200) = %v, left %d", err, v.GetAge())
+	}
+}
+`)
+}
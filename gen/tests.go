@@ -0,0 +1,111 @@
+package gen
+
+import (
+	"bytes"
+	"go/types"
+	"text/template"
+)
+
+var testTemplate = template.Must(template.New("test").Parse(`
+func Test{{.Name}}Accessors(t *testing.T) {
+	tests := []struct {
+		field string
+		check func(v *{{.Name}}) (got, want interface{})
+	}{
+{{- range .Cases}}
+		{"{{.Field}}", func(v *{{$.Name}}) (got, want interface{}) {
+			var value {{.Type}} = {{.Value}}
+			v.{{.Setter}}(value)
+			return v.{{.Getter}}(), value
+		}},
+{{- end}}
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, want := tt.check(new({{.Name}}))
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+`))
+
+// roundTrip is a field whose setter and getter are tested together.
+type roundTrip struct {
+	Field          string
+	Getter, Setter string
+	Type           string // type of the value set
+	Value          string // value set, preferably not the zero value
+}
+
+// testKey returns the key of the output of -with-tests for the type in
+// buf and imports. It can't clash with a type name.
+func testKey(typeName string) string {
+	return typeName + " test"
+}
+
+// newRoundTrip returns the round trip test of the accessors a of field,
+// or false when there is no value to test them with.
+func (g *Generator) newRoundTrip(st *StructInfo, a accessor, field StructFieldInfo) (roundTrip, bool) {
+	if len(st.TypeParams) > 0 || a.Immutable || a.Load != "" {
+		return roundTrip{}, false
+	}
+	t := g.fieldType(field)
+	if t == nil {
+		return roundTrip{}, false
+	}
+	rt := roundTrip{Field: a.Field, Getter: a.Getter, Setter: a.Setter, Type: a.Type}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			rt.Value = "true"
+		case u.Info()&types.IsString != 0:
+			rt.Value = `"a"`
+		case u.Info()&types.IsNumeric != 0:
+			rt.Value = "1"
+		default:
+			return roundTrip{}, false
+		}
+	case *types.Pointer:
+		rt.Value = "new(" + g.typeString(testKey(st.Name), u.Elem()) + ")"
+	case *types.Slice:
+		rt.Value = "make(" + a.Type + ", 1)"
+	case *types.Map, *types.Chan:
+		rt.Value = "make(" + a.Type + ")"
+	case *types.Struct, *types.Array:
+		if a.SkipZero {
+			return roundTrip{}, false
+		}
+		rt.Value = a.Type + "{}"
+	case *types.Interface:
+		if !u.Empty() {
+			return roundTrip{}, false
+		}
+		rt.Value = "1"
+	default:
+		return roundTrip{}, false
+	}
+	for _, imp := range field.Imports {
+		g.addImport(testKey(st.Name), imp)
+	}
+	return rt, true
+}
+
+// genTests writes the round trip tests of the type to the -with-tests
+// output.
+func (g *Generator) genTests(st *StructInfo, cases []roundTrip) {
+	if len(cases) == 0 {
+		return
+	}
+	key := testKey(st.Name)
+	g.addImport(key, Import{Path: "reflect"})
+	g.addImport(key, Import{Path: "testing"})
+	var buf bytes.Buffer
+	testTemplate.Execute(&buf, struct {
+		Name  string
+		Cases []roundTrip
+	}{st.Name, cases})
+	g.Printf(key, "%s", buf.Bytes())
+}
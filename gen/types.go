@@ -0,0 +1,138 @@
+package gen
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// typeOf returns the checked type of the type expression expr, or nil
+// when the type checker has no information about it.
+func (g *Generator) typeOf(expr ast.Expr) types.Type {
+	if expr == nil || g.pkg.info == nil {
+		return nil
+	}
+	return g.pkg.info.TypeOf(expr)
+}
+
+// fieldType returns the checked type of the field, or nil when the type
+// checker has no information about it.
+func (g *Generator) fieldType(field StructFieldInfo) types.Type {
+	if field.typ != nil {
+		return field.typ
+	}
+	return g.typeOf(field.expr)
+}
+
+// zeroOf returns the Go expression of the zero value for the type t,
+// whose source form is typeName.
+func zeroOf(t types.Type, typeName string) string {
+	if t == nil {
+		return "*new(" + typeName + ")"
+	}
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + typeName + ")"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil"
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil"
+	}
+	return typeName + "{}"
+}
+
+// isBool reports whether t is a boolean type.
+func isBool(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsBoolean != 0
+}
+
+// isString reports whether t is a string type.
+func isString(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+// isFuncOrChan reports whether t is a function or channel type, whose
+// values can't be printed meaningfully.
+func isFuncOrChan(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Signature, *types.Chan:
+		return true
+	}
+	return false
+}
+
+// comparable reports whether a value of type t, nil if unknown, can be
+// compared against its zero value with ==.
+func (g *Generator) comparable(t types.Type) bool {
+	if t == nil {
+		return true
+	}
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Map, *types.Signature:
+		return true
+	}
+	return types.Comparable(t)
+}
+
+// typeString returns the source form of t as written in the output for
+// the type stName, recording the imports it needs.
+func (g *Generator) typeString(stName string, t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg.types {
+			return ""
+		}
+		return g.importName(stName, p)
+	})
+}
+
+// holdsLock reports whether a value of type t holds a lock: a value whose
+// pointer has Lock and Unlock methods, such as sync.Mutex and the
+// sync/atomic types, directly or in a struct field or array element. Such
+// values must not be copied, as go vet's copylocks check reports.
+func holdsLock(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface:
+		return false
+	}
+	methods := types.NewMethodSet(types.NewPointer(t))
+	if methods.Lookup(nil, "Lock") != nil && methods.Lookup(nil, "Unlock") != nil {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Array:
+		return holdsLock(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if holdsLock(u.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// atomicType returns the name of the sync/atomic type t, such as Int64 or
+// Value, and whether t is one.
+func atomicType(t types.Type) (string, bool) {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "sync/atomic" {
+		return "", false
+	}
+	return named.Obj().Name(), true
+}
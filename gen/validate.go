@@ -0,0 +1,118 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+)
+
+// Constraint options of the access tag, enforced by the setter:
+// access:"w,min=0,max=100" or access:"w,nonempty".
+const (
+	AccessMin      = "min"
+	AccessMax      = "max"
+	AccessNonEmpty = "nonempty"
+)
+
+// What a setter does with a value breaking a constraint, chosen by
+// -validate.
+const (
+	ValidatePanic = "panic" // panic, leaving the field alone
+	ValidateClamp = "clamp" // assign the nearest bound, or leave the field alone
+	ValidateError = "error" // return an error, leaving the field alone
+)
+
+// check is a constraint a setter enforces on its parameter.
+type check struct {
+	Cond    string // condition on param breaking the constraint
+	Clamp   string // value param is clamped to, if there is one
+	Message string // description of the broken constraint
+}
+
+// checks returns the constraints of the field's tag. min and max bound
+// the value of a number and the length of a string, slice or map;
+// nonempty rejects empty strings, slices and maps and nil pointers.
+func (g *Generator) checks(stName string, field StructFieldInfo) ([]check, error) {
+	unsupported := func(format string, args ...interface{}) error {
+		return &Error{Kind: ErrUnsupported, Type: stName, Field: field.Name, Err: fmt.Errorf(format, args...)}
+	}
+	invalid := func(format string, args ...interface{}) error {
+		return &Error{Kind: ErrInvalidOption, Type: stName, Field: field.Name, Err: fmt.Errorf(format, args...)}
+	}
+	var t types.Type
+	if ft := g.fieldType(field); ft != nil {
+		t = ft.Underlying()
+	}
+	basic, _ := t.(*types.Basic)
+	number := basic != nil && basic.Info()&(types.IsInteger|types.IsFloat) != 0
+	var length bool
+	switch t.(type) {
+	case *types.Slice, *types.Map:
+		length = true
+	case *types.Basic:
+		length = basic.Info()&types.IsString != 0
+	}
+
+	var checks []check
+	what := stName + "." + field.Name
+	for _, key := range []string{AccessMin, AccessMax} {
+		bound, ok := field.OptionValue(key)
+		if !ok {
+			continue
+		}
+		op, word := "<", "at least"
+		if key == AccessMax {
+			op, word = ">", "at most"
+		}
+		switch {
+		case number:
+			if err := parseBound(bound, basic); err != nil {
+				return nil, invalid("%s=%s: %s", key, bound, err)
+			}
+			checks = append(checks, check{
+				Cond:    "param " + op + " " + bound,
+				Clamp:   bound,
+				Message: fmt.Sprintf("%s must be %s %s", what, word, bound),
+			})
+		case length:
+			if n, err := strconv.Atoi(bound); err != nil || n < 0 {
+				return nil, invalid("%s=%s: want a length", key, bound)
+			}
+			checks = append(checks, check{
+				Cond:    "len(param) " + op + " " + bound,
+				Message: fmt.Sprintf("the length of %s must be %s %s", what, word, bound),
+			})
+		default:
+			return nil, unsupported("%s needs a number, string, slice or map, got %s", key, field.Type)
+		}
+	}
+	if field.HasOption(AccessNonEmpty) {
+		switch t.(type) {
+		case *types.Pointer, *types.Interface:
+			checks = append(checks, check{Cond: "param == nil", Message: what + " must not be nil"})
+		default:
+			if !length {
+				return nil, unsupported("%s needs a string, slice, map or pointer, got %s", AccessNonEmpty, field.Type)
+			}
+			checks = append(checks, check{Cond: "len(param) == 0", Message: what + " must not be empty"})
+		}
+	}
+	return checks, nil
+}
+
+// parseBound reports whether bound is a constant of the numeric type t.
+func parseBound(bound string, t *types.Basic) error {
+	var err error
+	switch {
+	case t.Info()&types.IsUnsigned != 0:
+		_, err = strconv.ParseUint(bound, 0, 64)
+	case t.Info()&types.IsInteger != 0:
+		_, err = strconv.ParseInt(bound, 0, 64)
+	default:
+		_, err = strconv.ParseFloat(bound, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("want a constant of type %s", t.Name())
+	}
+	return nil
+}
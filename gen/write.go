@@ -0,0 +1,145 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Write writes the output for the types to the file name, or to the
+// standard output if name is "-", and their tests, if any, to the _test.go
+// file next to it.
+func (g *Generator) Write(name string, typeNames ...string) error {
+	src, err := g.Bytes(typeNames...)
+	if err != nil {
+		return err
+	}
+	if err := g.emit(name, src, typeNames); err != nil {
+		return err
+	}
+	var tests []string
+	for _, typeName := range typeNames {
+		if g.buf[testKey(typeName)] != nil {
+			tests = append(tests, testKey(typeName))
+		}
+	}
+	if len(tests) == 0 {
+		return nil
+	}
+	src, err = g.Bytes(tests...)
+	if err != nil {
+		return err
+	}
+	return g.emit(strings.TrimSuffix(name, ".go")+"_test.go", src, typeNames)
+}
+
+// generatedCode matches the comment marking a file as generated code.
+var generatedCode = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// emit writes src to the file name, unless it already holds src. A file
+// that is not generated code is only overwritten with Force. With
+// -check it only records the file as stale when its content differs, with
+// -dry-run it prints the diff between the file and src.
+func (g *Generator) emit(name string, src []byte, typeNames []string) error {
+	if name == "-" {
+		if _, err := g.stdout.Write(src); err != nil {
+			return &Error{Kind: ErrIO, Type: strings.Join(typeNames, ","), Err: err}
+		}
+		g.out.record(g.pkg, name, nil)
+		return nil
+	}
+	if g.dryRun {
+		old, err := os.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			return &Error{Kind: ErrIO, Type: strings.Join(typeNames, ","), Err: err}
+		}
+		from := name
+		if err != nil {
+			from = "/dev/null"
+		}
+		fmt.Fprint(g.stdout, UnifiedDiff(from, name, old, src))
+		return nil
+	}
+	if g.check {
+		old, err := os.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			return &Error{Kind: ErrIO, Type: strings.Join(typeNames, ","), Err: err}
+		}
+		if !bytes.Equal(old, src) {
+			g.out.mu.Lock()
+			g.out.stale = append(g.out.stale, name)
+			g.out.mu.Unlock()
+		}
+		return nil
+	}
+	old, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return &Error{Kind: ErrIO, Type: strings.Join(typeNames, ","), Err: err}
+	}
+	if err == nil {
+		// Leave the file alone when it is up to date, not to touch its
+		// modification time and trigger rebuilds.
+		if bytes.Equal(old, src) {
+			g.out.record(g.pkg, name, &g.out.unchanged)
+			return nil
+		}
+		if !g.force && len(old) > 0 && !generatedCode.Match(old) {
+			return &Error{Kind: ErrNotGenerated, Type: strings.Join(typeNames, ","),
+				Err: fmt.Errorf("%s has no \"Code generated ... DO NOT EDIT.\" comment", name)}
+		}
+	}
+	if err := os.WriteFile(name, src, 0644); err != nil {
+		return &Error{Kind: ErrIO, Type: strings.Join(typeNames, ","), Err: err}
+	}
+	g.out.record(g.pkg, name, &g.out.written)
+	return nil
+}
+
+// OutputName evaluates the output file name pattern for a type, e.g.
+// {{.Type | snake}}_gen.go.
+func OutputName(pattern, typeName, pkgName string) (string, error) {
+	t, err := template.New("output").Funcs(template.FuncMap{
+		"snake": snakeCase,
+		"kebab": kebabCase,
+		"lower": strings.ToLower,
+	}).Parse(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %s", err)
+	}
+	var b strings.Builder
+	err = t.Execute(&b, struct{ Type, Package string }{typeName, pkgName})
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %s", err)
+	}
+	name := b.String()
+	if !strings.HasSuffix(name, ".go") || strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("pattern %q gives %q for %s, want a .go file name", pattern, name, typeName)
+	}
+	return name, nil
+}
+
+// Stale returns the files found out of date by Write with Check.
+func (g *Generator) Stale() []string {
+	g.out.mu.Lock()
+	defer g.out.mu.Unlock()
+	return append([]string(nil), g.out.stale...)
+}
+
+// Written returns the files written by Write.
+func (g *Generator) Written() []string {
+	g.out.mu.Lock()
+	defer g.out.mu.Unlock()
+	return append([]string(nil), g.out.written...)
+}
+
+// Unchanged returns the files Write left alone because they were up to
+// date.
+func (g *Generator) Unchanged() []string {
+	g.out.mu.Lock()
+	defer g.out.mu.Unlock()
+	return append([]string(nil), g.out.unchanged...)
+}
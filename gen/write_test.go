@@ -0,0 +1,50 @@
+package gen
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestOutputName(t *testing.T) {
+	tests := []struct {
+		pattern, typeName, want string
+	}{
+		{"{{.Type | snake}}_gen.go", "HTTPServer", "http_server_gen.go"},
+		{"{{.Type | kebab}}.go", "UserAccount", "user-account.go"},
+		{"{{.Package}}_{{.Type | lower}}.go", "User", "models_user.go"},
+	}
+	for _, test := range tests {
+		got, err := OutputName(test.pattern, test.typeName, "models")
+		if err != nil || got != test.want {
+			t.Errorf("OutputName(%q, %q) = %q, %v, want %q", test.pattern, test.typeName, got, err, test.want)
+		}
+	}
+	for _, pattern := range []string{"{{.Type}}.txt", "{{.Type}}/x.go", "{{.Missing}}.go", "{{.Type"} {
+		if got, err := OutputName(pattern, "User", "models"); err == nil {
+			t.Errorf("OutputName(%q) = %q, want an error", pattern, got)
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`// Code generated by "accessor"; DO NOT EDIT.`, true},
+		{`// Code generated by "accessor -type=User -equal"; DO NOT EDIT.`, true},
+		{`// Code generated by "accessorvet"; DO NOT EDIT.`, false},
+		{`// Code generated by "accessor-x -type=User"; DO NOT EDIT.`, false},
+		{`// Code generated by "stringer"; DO NOT EDIT.`, false},
+	}
+	for _, test := range tests {
+		file, err := parser.ParseFile(token.NewFileSet(), "", test.header+"\n\npackage p\n", parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IsGenerated(file); got != test.want {
+			t.Errorf("IsGenerated(%s) = %v, want %v", test.header, got, test.want)
+		}
+	}
+}
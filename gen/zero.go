@@ -0,0 +1,103 @@
+package gen
+
+import (
+	"go/types"
+	"strings"
+)
+
+// zeroCond returns the condition testing the addressable expression x of
+// type t, written in the output of stName, against the zero value. Slices
+// and maps are zero when empty, pointers and the like when nil, and types
+// with an IsZero() bool method, such as time.Time, when it says so. The
+// types that can't be compared otherwise go through reflect.
+func (g *Generator) zeroCond(stName, x string, t types.Type) string {
+	if p, ok := t.(*types.TypeParam); ok {
+		if types.Comparable(p) {
+			return x + " == *new(" + g.typeString(stName, t) + ")"
+		}
+		return g.reflectZero(stName, x)
+	}
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Map:
+		return "len(" + x + ") == 0"
+	case *types.Pointer, *types.Chan, *types.Signature, *types.Interface:
+		return x + " == nil"
+	}
+	if g.hasIsZero(t) {
+		return x + ".IsZero()"
+	}
+	if !types.Comparable(t) {
+		return g.reflectZero(stName, x)
+	}
+	zero := zeroOf(t, g.typeString(stName, t))
+	if strings.HasSuffix(zero, "}") {
+		zero = "(" + zero + ")"
+	}
+	return x + " == " + zero
+}
+
+// reflectZero returns the condition testing x against the zero value with
+// reflect.
+func (g *Generator) reflectZero(stName, x string) string {
+	g.addImport(stName, Import{Path: "reflect"})
+	return "reflect.ValueOf(&" + x + ").Elem().IsZero()"
+}
+
+// hasIsZero reports whether values of type t have an IsZero() bool
+// method: the types of the package whose IsZero method is generated, and
+// the types with one declared outside the files generated in the package.
+func (g *Generator) hasIsZero(t types.Type) bool {
+	if named, ok := types.Unalias(t).(*types.Named); ok && named.Obj().Pkg() == g.pkg.types && g.isZero && g.generating[named.Obj().Name()] {
+		return true
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, g.pkg.types, "IsZero")
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == g.pkg.types && g.generatedFile(fn.Pos()) != "" {
+		return false
+	}
+	sig := fn.Signature()
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && isBool(sig.Results().At(0).Type())
+}
+
+// genIsZero produces the IsZero method of the struct type, reporting
+// whether all the valueFields are zero, as tested by zeroCond on their
+// loaded value, under the read lock when the accessors lock. A nil
+// receiver is zero.
+func (g *Generator) genIsZero(st *StructInfo, receiver string) string {
+	conds := []string{receiver + " == nil"}
+	for _, field := range g.valueFields(st) {
+		value, t := g.load(st, receiver, field)
+		conds = append(conds, g.zeroCond(st.Name, value, t))
+	}
+	w := &codeWriter{}
+	w.line("// IsZero reports whether all the fields of %s are zero.", receiver)
+	w.line("func (%s *%s) IsZero() bool {", receiver, st.TypeName())
+	w.indent++
+	switch mu := g.structLock(st); {
+	case len(conds) == 1:
+		w.line("return true")
+	case mu != nil:
+		w.line("if %s {", conds[0])
+		w.line("\treturn true")
+		w.line("}")
+		mu.readLock(w, receiver)
+		w.line("return %s", strings.Join(conds[1:], " &&\n\t\t"))
+	default:
+		w.line("return %s ||\n\t\t%s", conds[0], strings.Join(conds[1:], " &&\n\t\t"))
+	}
+	w.indent--
+	w.line("}")
+	return w.String()
+}
+
+// nonZero negates the condition of zeroCond. A boolean is its own
+// condition.
+func nonZero(cond string) string {
+	if x, ok := strings.CutSuffix(cond, " == false"); ok {
+		return x
+	}
+	if i := strings.LastIndex(cond, " == "); i >= 0 {
+		return cond[:i] + " != " + cond[i+len(" == "):]
+	}
+	return "!" + cond
+}
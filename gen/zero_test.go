@@ -0,0 +1,68 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": `package p
+
+type Flag bool
+
+type Config struct {
+	Host    string
+	Port    int
+	Debug   bool
+	Verbose Flag
+	Tags    []string
+}
+`})
+	cfg := DefaultConfig()
+	cfg.Merge = true
+	src := generate(t, cfg, dir, "Config")
+	if strings.Contains(src, "!= false") {
+		t.Errorf("Merge compares a boolean with false:\n%s", src)
+	}
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	c := Config{Host: "a", Port: 1, Tags: []string{"x"}}
+	c.Merge(&Config{Port: 2, Debug: true, Verbose: true})
+	if c.Host != "a" || c.Port != 2 || !c.Debug || !bool(c.Verbose) || len(c.Tags) != 1 {
+		t.Errorf("Merge left %+v", c)
+	}
+}
+`)
+}
+
+func TestIsZeroAtomicFields(t *testing.T) {
+	dir := testPackage(t, map[string]string{"p.go": atomicSrc})
+	cfg := DefaultConfig()
+	cfg.IsZero = true
+	cfg.ThreadSafe = true
+	src := generate(t, cfg, dir, "Counter")
+	contains(t, src, "c.mu.RLock()", "c.hits.Load() == 0")
+	runTests(t, dir, src, `package p
+
+import "testing"
+
+func TestIsZero(t *testing.T) {
+	c := &Counter{}
+	if !c.IsZero() {
+		t.Error("zero counter is not IsZero")
+	}
+	c.SetHits(1)
+	if c.IsZero() {
+		t.Error("hits are not tested")
+	}
+	c.SetHits(0)
+	c.SetTotal(1)
+	if c.IsZero() {
+		t.Error("total is not tested")
+	}
+}
+`)
+}
@@ -1,8 +1,17 @@
 module github.com/lazypandatg/accessor
 
-go 1.12
+go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/fatih/structtag v1.2.0
-	golang.org/x/tools v0.0.0-20201109182053-3db8fd265862
+	github.com/fsnotify/fsnotify v1.8.0
+	golang.org/x/tools v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.35.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
 )
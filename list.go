@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lazypandatg/accessor/gen"
+)
+
+// packagePlan is a package in the output of accessor list -json.
+type packagePlan struct {
+	Package string     `json:"package"`
+	Types   []gen.Plan `json:"types"`
+}
+
+// runList implements "accessor list": it generates the types of the
+// packages named by args as the flags configure, but writes nothing;
+// instead it prints each type with its fields, their access modes and
+// the methods that would be generated for them, to debug struct tags.
+// With -json it prints them as a JSON array of packages.
+func runList(args []string) {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of accessor list:\n")
+		fmt.Fprintf(os.Stderr, "\taccessor list [-json] [flags] [packages] # default ., every struct type unless -type is given\n")
+		fmt.Fprintf(os.Stderr, "Flags are those of accessor, see accessor -help.\n")
+	}
+	flag.CommandLine.Parse(args)
+	loadDefaults()
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	types := splitTypes(*typeNames)
+
+	g := newGenerator()
+	if err := g.Load(patterns...); err != nil {
+		log.Fatal(err)
+	}
+	for _, err := range g.SyntaxErrors() {
+		fail(err)
+	}
+	plans := []packagePlan{}
+	for _, pkg := range g.Packages() {
+		pg := g.Fork()
+		pg.SetPackage(pkg)
+		plans = append(plans, packagePlan{Package: pkg.Path(), Types: []gen.Plan{}})
+		if len(g.Packages()) > 1 && !*jsonList {
+			fmt.Printf("# %s\n", pkg.Path())
+		}
+		names := types
+		if len(names) == 0 {
+			all, err := pg.StructNames()
+			if err != nil {
+				fail(err)
+				continue
+			}
+			names = excludeTypes(all, *exclude)
+		}
+		pg.SetTypes(names)
+		typeErrs := pg.GenerateTypes(names, *jobs)
+		for _, typeName := range names {
+			if err := typeErrs[typeName]; err != nil {
+				if len(g.Packages()) > 1 && errors.Is(err, gen.ErrTypeNotFound) {
+					continue
+				}
+				fail(err)
+				continue
+			}
+			plan, ok := pg.Plan(typeName)
+			switch {
+			case !ok:
+				fail(fmt.Errorf("%s: no plan was recorded", typeName))
+			case *jsonList:
+				plans[len(plans)-1].Types = append(plans[len(plans)-1].Types, plan)
+			default:
+				printPlan(plan)
+			}
+		}
+	}
+	if *jsonList {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plans); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if summarize() {
+		os.Exit(1)
+	}
+}
+
+// printPlan prints the fields of the plan with their access modes and
+// methods, followed by the methods of the type as a whole.
+func printPlan(plan gen.Plan) {
+	fmt.Printf("type %s\n", plan.Type)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "FIELD\tTYPE\tACCESS\tMETHODS\n")
+	for _, field := range plan.Fields {
+		access := strings.Join(field.Access, ",")
+		if field.Skip {
+			access = "-"
+		}
+		if options := strings.Join(field.Options, ","); options != "" {
+			access += " (" + options + ")"
+		}
+		methods := strings.Join(field.Methods, ", ")
+		if methods == "" {
+			methods = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", field.Name, field.Type, access, methods)
+	}
+	tw.Flush()
+	if len(plan.Methods) > 0 {
+		fmt.Printf("methods of the type: %s\n", strings.Join(plan.Methods, ", "))
+	}
+	fmt.Println()
+}
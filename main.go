@@ -1,31 +1,94 @@
 package main
 
 import (
-	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/fatih/structtag"
-	"go/ast"
-	"io/ioutil"
-
-	"go/printer"
-	"go/token"
-	"go/types"
-	"golang.org/x/tools/go/packages"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
-	"text/template"
-)
+	"sync"
 
-const AccessRead = "r"
-const AccessWrite = "w"
-const AccessTagName = "access"
+	"github.com/lazypandatg/accessor/gen"
+)
 
 var (
-	typeNames = flag.String("type", "", "comma-separated list of type names; must be set")
-	output    = flag.String("output", "", "output file name; default srcdir/<type>_accessor.go")
+	typeNames       = flag.String("type", "", "comma-separated list of type names; must be set unless -all is given")
+	tagName         = flag.String("tag", gen.AccessTagName, "name of the struct tag holding the access modes")
+	all             = flag.Bool("all", false, "generate accessors for every struct type of the package")
+	output          = flag.String("output", "", "output file name, holding the output for all types, or - for the standard output; default srcdir/<type>_accessor.go")
+	outputPattern   = flag.String("output-pattern", "", "template for the output file name of each type, e.g. {{.Type | snake}}_gen.go; fields .Type and .Package, funcs snake, kebab and lower")
+	exclude         = flag.String("exclude", "", "comma-separated list of type names skipped by -all")
+	embedded        = flag.Bool("embedded", false, "also generate accessors for fields promoted from embedded structs of the package")
+	deepCopy        = flag.Bool("deepcopy", false, "also generate DeepCopyInto and DeepCopy methods")
+	clone           = flag.Bool("clone", false, "also generate a Clone method returning a deep copy, with the DeepCopyInto and DeepCopy methods it calls")
+	equal           = flag.Bool("equal", false, "also generate an Equal method comparing the fields, for all types; otherwise only for the types with an //accessor:equal comment")
+	stringer        = flag.Bool("stringer", false, "also generate a String method printing the fields, with those tagged access:\"secret\" as ***")
+	goStringer      = flag.Bool("gostring", false, "also generate a GoString method printing Go syntax that builds a copy of the value, setting unexported fields through the accessors")
+	slogFlag        = flag.Bool("slog", false, "also generate a LogValue method logging the readable fields, but those tagged access:\"secret\", as a slog group")
+	zap             = flag.Bool("zap", false, "also generate a MarshalLogObject method logging the readable fields, but those tagged access:\"secret\", as a zap object")
+	maps            = flag.Bool("maps", false, "also generate ToMap, returning the readable fields by name, and FromMap, setting the writable ones")
+	byName          = flag.Bool("by-name", false, "also generate GetField and SetField, reaching the fields by name through a switch instead of reflection")
+	fieldNames      = flag.Bool("field-names", false, "also generate a <Type>Field string type with a <Type>Field<Name> constant holding the name of each field with accessors")
+	fieldInfo       = flag.Bool("fields", false, "also generate a Fields method describing the fields with accessors, returning values of the type github.com/lazypandatg/accessor/meta.FieldInfo without importing it")
+	isZero          = flag.Bool("is-zero", false, "also generate an IsZero method reporting whether all the fields are zero, slices and maps counting as zero when empty")
+	reset           = flag.Bool("reset", false, "also generate a Reset method setting the writable fields to their zero values, for values recycled through a sync.Pool")
+	merge           = flag.Bool("merge", false, "also generate a Merge method copying the fields of another value that are not zero, for layering configurations")
+	diff            = flag.Bool("diff", false, "also generate a Diff method listing the fields differing from another value")
+	patch           = flag.Bool("patch", false, "also generate a <Type>Patch struct of pointers to the writable fields and an Apply method setting the non-nil ones, for PATCH requests")
+	constructor     = flag.Bool("constructor", false, "also generate a New<Type> constructor taking the fields tagged required and setting them through their setters")
+	safe            = flag.Bool("safe", false, "also generate a Safe<Type> wrapper holding the value with a sync.RWMutex, whose accessors lock it")
+	funcs           = flag.Bool("funcs", false, "generate the accessors as package-level functions taking the value, such as GetUserName(u *User), instead of methods")
+	unexported      = flag.Bool("unexported", false, "generate unexported getters and setters, such as getName and setName, for access controlled within the package")
+	audit           = flag.Bool("audit", false, "make setters call auditLog(field string, old, new interface{}) before assigning")
+	builder         = flag.Bool("builder", false, "also generate a <Type>Builder with With<Field> methods and Build")
+	options         = flag.Bool("options", false, "also generate New<Type>(opts ...<Type>Option) and With<Type><Field> options")
+	immutable       = flag.Bool("immutable", false, "generate With<Field> methods returning a modified copy instead of setters")
+	threadSafe      = flag.Bool("threadsafe", false, "make accessors lock the sync.Mutex or sync.RWMutex field of the struct")
+	nilSafe         = flag.Bool("nil-safe", false, "make getters return the zero value when called on a nil receiver")
+	okGetters       = flag.Bool("ok", false, "also generate Get<Field>Ok() (T, bool) for pointer fields, dereferencing them and reporting presence")
+	defensive       = flag.Bool("defensive", false, "make getters of slice and map fields return a copy")
+	getterPrefix    = flag.String("getter-prefix", "Get", "prefix of the getter names")
+	setterPrefix    = flag.String("setter-prefix", "Set", "prefix of the setter names")
+	boolPrefix      = flag.String("bool-prefix", "", "prefix of the getters of bool fields, e.g. Is; default the getter prefix")
+	initialismsFlag = flag.String("initialisms", "", "comma-separated initialisms written in capitals in method names, in addition to the built-in ones such as ID and URL")
+	legacyNames     = flag.Bool("legacy-names", false, "use field names in method names as written, without capitals for the first letter or initialisms, e.g. Getname for name")
+	receiver        = flag.String("receiver", "", "receiver name of the generated methods; default the first letter of the type, avoiding names used by the methods")
+	receiverKind    = flag.String("receiver-kind", gen.ReceiverPointer, "receiver of the getters, value or pointer; setters always use a pointer. A type can choose with an //accessor:receiver=value directive")
+	iface           = flag.Bool("interface", false, "also generate a <Type>Accessor interface declaring the accessors")
+	mock            = flag.Bool("mock", false, "also generate the <Type>Accessor interface and a Mock<Type>Accessor stub implementing it")
+	view            = flag.Bool("view", false, "also generate a read-only <Type>View exposing only the getters, returned by the View method")
+	withTests       = flag.Bool("with-tests", false, "also write <output>_test.go with round trip tests of the getters and setters")
+	singleFile      = flag.Bool("single-file", false, "write the output for all types of a package to one file, accessors_gen.go unless -output is set")
+	check           = flag.Bool("check", false, "write nothing; exit with status 1, listing the files, if the generated files are out of date")
+	dryRun          = flag.Bool("dry-run", false, "write nothing; print a unified diff of the changes to the generated files")
+	includeTests    = flag.Bool("include-tests", false, "also generate for the struct types of the _test.go files, writing to _test.go files")
+	loadTags        = flag.String("tags", "", "comma-separated list of build tags the packages are loaded with, selecting the files guarded by them")
+	goos            = flag.String("goos", "", "GOOS the packages are loaded for, selecting files such as _linux.go; default the host's")
+	goarch          = flag.String("goarch", "", "GOARCH the packages are loaded for; default the host's")
+	buildTags       = flag.String("build-tags", "", "build constraint written as a //go:build line in the generated files, e.g. 'linux && !race'")
+	headerFile      = flag.String("header-file", "", "file whose text, such as a license, is written above the generated-code comment of the output files")
+	force           = flag.Bool("force", false, "overwrite output files that are not marked as generated code")
+	strict          = flag.Bool("strict", false, "stop at the first error instead of generating the remaining types and reporting all errors at the end")
+	templateDir     = flag.String("template-dir", "", "directory of templates replacing the built-in getter.tmpl, getter_ok.tmpl, setter.tmpl and wither.tmpl")
+	validate        = flag.String("validate", gen.ValidatePanic, "what setters do with a value breaking the min, max or nonempty constraint of the field: panic, clamp it to the bound (or ignore it), or error to return an error")
+	onConflict      = flag.String("on-conflict", gen.ConflictError, "what to do with a generated method named like a hand-written method of the type: error, skip it, or overwrite to generate it anyway")
+	watch           = flag.Bool("watch", false, "keep running and regenerate whenever a Go file of the packages changes")
+	cache           = flag.Bool("cache", false, "skip the packages whose files, and those of the packages of the module they import, are unchanged since they were generated with the same flags")
+	jobs            = flag.Int("jobs", runtime.GOMAXPROCS(0), "number of packages, and of types in each, generated in parallel")
+	goStyle         = flag.Bool("go-style", false, "name getters after the field without a prefix, as in Effective Go; overrides -getter-prefix")
+	chain           = flag.Bool("chain", false, "make setters return the receiver so calls can be chained")
+	errSetters      = flag.Bool("errset", false, "make setters return an error, reporting the broken constraints of the field instead of panicking")
+	trackChanges    = flag.Bool("track-changes", false, "make setters record the fields set in the map[string]bool field tagged access:\"changes\", and generate ChangedFields and ClearChanges")
+	observers       = flag.Bool("observers", false, "also generate On<Field>Change methods registering functions the setters call with the old and new value, kept in the map[string][]interface{} field tagged access:\"observers\"")
+	sortFields      = flag.Bool("sort-fields", false, "emit accessors sorted by field name instead of source order")
+	columns         = flag.Bool("columns", false, "also generate <Field>Column methods, named like the accessors, returning the column name of each field")
+	columnTag       = flag.String("column-tag", "db", "struct tag holding the column name for -columns")
+	listTypes       = flag.Bool("list-types", false, "list struct types in the package with their field counts; no files are written")
+	printVersion    = flag.Bool("version", false, "print the version of the accessor command and exit")
+	jsonList        = flag.Bool("json", false, "with accessor list, print the packages, types, fields and methods as JSON for other tools")
 )
 
 // Usage is a replacement usage function for the flags package.
@@ -33,6 +96,13 @@ func Usage() {
 	fmt.Fprintf(os.Stderr, "Usage of accessor:\n")
 	fmt.Fprintf(os.Stderr, "\taccessor [flags] -type T [directory]\n")
 	fmt.Fprintf(os.Stderr, "\taccessor [flags] -type T files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "\taccessor [flags] -all [directory]\n")
+	fmt.Fprintf(os.Stderr, "\taccessor [flags] -all ./... # Every package of the module\n")
+	fmt.Fprintf(os.Stderr, "\taccessor -list-types [directory]\n")
+	fmt.Fprintf(os.Stderr, "\taccessor fix [-dry-run] [packages] # Rewrite field access to accessor calls\n")
+	fmt.Fprintf(os.Stderr, "\taccessor list [flags] [packages] # Print what would be generated\n")
+	fmt.Fprintf(os.Stderr, "\taccessor clean [-dry-run] [directories] # Delete the generated files\n")
+	fmt.Fprintf(os.Stderr, "\taccessor completion bash|zsh|fish # Print the shell completion script\n")
 	fmt.Fprintf(os.Stderr, "For more information, see:\n")
 	fmt.Fprintf(os.Stderr, "\thttps://gitee.com/dwdcth/accessor.git\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
@@ -42,13 +112,47 @@ func Usage() {
 func main() {
 	log.SetFlags(0)
 	log.SetPrefix("accessor: ")
+	defer exitStrict()
+	if len(os.Args) > 1 && os.Args[1] == "fix" {
+		runFix(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		runCompletion(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "clean" {
+		runClean(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "list" {
+		runList(os.Args[2:])
+		return
+	}
 	flag.Usage = Usage
 	flag.Parse()
-	if len(*typeNames) == 0 {
+	if *printVersion {
+		fmt.Printf("accessor %s\n", version())
+		return
+	}
+	loadDefaults()
+	types := splitTypes(*typeNames)
+	if len(types) == 0 && !*all && !*listTypes {
 		flag.Usage()
 		os.Exit(2)
 	}
-	types := strings.Split(*typeNames, ",")
+	if *jsonList {
+		log.Fatal("-json is only used by accessor list")
+	}
+	if *output == "-" && (*check || *dryRun) {
+		log.Fatal("-check and -dry-run cannot be used with -output -")
+	}
+	if *output == "-" && *withTests {
+		log.Fatal("-with-tests cannot be used with -output -")
+	}
+	if *outputPattern != "" && (*singleFile || *output != "") {
+		log.Fatal("-output-pattern cannot be used with -output or -single-file")
+	}
 
 	// We accept either one directory or a list of files. Which do we have?
 	args := flag.Args()
@@ -57,263 +161,453 @@ func main() {
 		args = []string{"."}
 	}
 
-	// Parse the package once.
-	var dir string
-	g := Generator{
-		buf: make(map[string]*bytes.Buffer),
-		//structInfo: make(map[string]StructFieldInfoArr), //一定不能初始化
-		walkMark: make(map[string]bool),
-	}
-	if len(args) == 1 && isDirectory(args[0]) {
-		dir = args[0]
-	} else {
-		dir = filepath.Dir(args[0])
-	}
-
-	//ParseStruct(dir, nil, "access")
-	g.parsePackage(args)
-
-	// Print the header and package clause.
-	// Run generate for each type.
-	for i, typeName := range types {
-		g.generate(typeName)
-		// AccessWrite to file.
-		outputName := *output
-		if outputName == "" {
-			baseName := fmt.Sprintf("%s_accessor.go", types[i])
-			outputName = filepath.Join(dir, strings.ToLower(baseName))
+	if *listTypes {
+		g := newGenerator()
+		if err := g.Load(args...); err != nil {
+			log.Fatal(err)
 		}
-		buf := g.buf[typeName]
-		var src = (buf).Bytes()
-		err := ioutil.WriteFile(outputName, src, 0644)
-		if err != nil {
-			log.Fatalf("writing output: %s", err)
+		for _, err := range g.SyntaxErrors() {
+			fail(err)
+		}
+		for _, pkg := range g.Packages() {
+			g.SetPackage(pkg)
+			if len(g.Packages()) > 1 {
+				fmt.Printf("# %s\n", pkg.Path())
+			}
+			if err := g.ListTypes(os.Stdout); err != nil {
+				fail(err)
+			}
+		}
+		if summarize() {
+			os.Exit(1)
 		}
+		return
 	}
 
-}
-
-// isDirectory reports whether the named file is a directory.
-func isDirectory(name string) bool {
-	info, err := os.Stat(name)
+	if *watch {
+		watchPackages(args, types)
+		return
+	}
+	_, ok, err := run(args, types)
 	if err != nil {
 		log.Fatal(err)
 	}
-	return info.IsDir()
-}
-
-// Generator holds the state of the analysis. Primarily used to buffer
-// the output for format.Source.
-type Generator struct {
-	buf        map[string]*bytes.Buffer // Accumulated output.
-	pkg        *Package                 // Package we are scanning.
-	structInfo map[string]StructFieldInfoArr
-	walkMark   map[string]bool
-}
-
-func (g *Generator) Printf(structName, format string, args ...interface{}) {
-	buf, ok := g.buf[structName]
 	if !ok {
-		buf = bytes.NewBufferString("")
-		g.buf[structName] = buf
+		os.Exit(1)
 	}
-	fmt.Fprintf(buf, format, args...)
-}
-
-// File holds a single parsed file and associated data.
-type File struct {
-	pkg     *Package  // Package to which this file belongs.
-	file    *ast.File // Parsed AST.
-	fileSet *token.FileSet
-	// These fields are reset for each type being generated.
-	typeName string // Name of the constant type.
-
-}
-
-type Package struct {
-	name  string
-	defs  map[*ast.Ident]types.Object
-	files []*File
 }
 
-// parsePackage analyzes the single package constructed from the patterns and tags.
-// parsePackage exits if there is an error.
-func (g *Generator) parsePackage(patterns []string) {
-	cfg := &packages.Config{
-		Mode:  packages.LoadSyntax,
-		Tests: false,
-	}
-	pkgs, err := packages.Load(cfg, patterns...)
+// loadDefaults sets the flags not given on the command line to the
+// defaults of the project, from the configuration file at the module root.
+func loadDefaults() {
+	config, err := findConfig(".")
 	if err != nil {
 		log.Fatal(err)
 	}
-	if len(pkgs) != 1 {
-		log.Fatalf("error: %d packages found", len(pkgs))
+	if config != "" {
+		if err := loadConfig(config); err != nil {
+			log.Fatal(err)
+		}
 	}
-	g.addPackage(pkgs[0])
 }
 
-// addPackage adds a type checked Package and its syntax files to the generator.
-func (g *Generator) addPackage(pkg *packages.Package) {
-	g.pkg = &Package{
-		name:  pkg.Name,
-		defs:  pkg.TypesInfo.Defs,
-		files: make([]*File, len(pkg.Syntax)),
+// splitTypes returns the type names of the comma-separated list.
+func splitTypes(list string) []string {
+	var types []string
+	for _, typeName := range strings.Split(list, ",") {
+		if typeName = strings.TrimSpace(typeName); typeName != "" {
+			types = append(types, typeName)
+		}
 	}
+	return types
+}
 
-	for i, file := range pkg.Syntax {
-		g.pkg.files[i] = &File{
-			file:    file,
-			pkg:     g.pkg,
-			fileSet: pkg.Fset,
+// run generates the output for the types, or for every struct type with
+// -all, of the packages matching the patterns. It returns the generator
+// and whether the run succeeded with the files up to date, or the error
+// that stopped it with -strict.
+func run(patterns, types []string) (g *gen.Generator, ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s, isStrict := r.(strictError)
+			if !isStrict {
+				panic(r)
+			}
+			ok, err = false, s.err
 		}
+	}()
+	errs = nil
+	g = newGenerator()
+	if err := g.Load(patterns...); err != nil {
+		log.Print(err)
+		return g, false, nil
+	}
+	for _, err := range g.SyntaxErrors() {
+		fail(err)
+	}
+
+	if len(g.Packages()) > 1 && *output != "" {
+		log.Print("-output cannot be used with several packages; use -output-pattern")
+		return g, false, nil
 	}
-}
 
-// generate produces the String method for the named type.
-func (g *Generator) generate(typeName string) {
-	for _, file := range g.pkg.files { //按包来的，读取包下的所有文件
-		// Set the state for this run of the walker.
-		file.typeName = typeName
-		//ast.Print(file.fileSet, file.file)
-		if file.file != nil {
+	if !*all {
+		// Report every type that is missing before writing anything.
+		missing, err := g.MissingTypes(types)
+		if err != nil {
+			fail(err)
+			return g, !summarize(), nil
+		}
+		for _, typeName := range missing {
+			log.Print(&gen.Error{Kind: gen.ErrTypeNotFound, Type: typeName})
+		}
+		if len(missing) > 0 {
+			return g, false, nil
+		}
+	}
 
-			structInfo, err := ParseStruct(file.file, file.fileSet, AccessTagName)
-			if err != nil {
-				fmt.Println("失败:" + err.Error())
-				return
+	// Generate the packages in parallel, then write their output in order.
+	pkgs := g.Packages()
+	results := make([]struct {
+		g     *gen.Generator
+		names []string
+		errs  map[string]error // errors by type
+		err   error            // error listing the types
+	}, len(pkgs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(1, *jobs))
+	for i, pkg := range pkgs {
+		if pkg.Cached() {
+			continue
+		}
+		// Fork here rather than in the goroutines, which change their
+		// forks as they run.
+		r := &results[i]
+		r.g = g.Fork()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			r.g.SetPackage(pkg)
+			r.names = types
+			if *all {
+				names, err := r.g.StructNames()
+				if err != nil {
+					r.err = err
+					return
+				}
+				r.names = excludeTypes(names, *exclude)
 			}
+			r.g.SetTypes(r.names)
+			r.errs = r.g.GenerateTypes(r.names, *jobs)
+		}()
+	}
+	wg.Wait()
 
-			for stName, info := range structInfo {
-				if stName != typeName {
+	for i, pkg := range pkgs {
+		r := results[i]
+		if pkg.Cached() {
+			continue
+		}
+		if r.err != nil {
+			fail(r.err)
+			continue
+		}
+		pg := r.g
+		var generated, generatedTests []string
+		for _, typeName := range r.names {
+			if err := r.errs[typeName]; err != nil {
+				if len(g.Packages()) > 1 && errors.Is(err, gen.ErrTypeNotFound) {
+					// The type may be declared in another package.
 					continue
 				}
-				g.Printf(stName, "// Code generated by \"accessor %s\"; DO NOT EDIT.\n", strings.Join(os.Args[1:], " "))
-				g.Printf(stName, "\n")
-				g.Printf(stName, "package %s\n", g.pkg.name)
-				g.Printf(stName, "\n")
-				for _, field := range info {
-					for _, access := range field.Access {
-						switch access {
-						case AccessWrite:
-							g.Printf(stName, "%s\n", genSetter(stName, field.Name, field.Type))
-						case AccessRead:
-							g.Printf(stName, "%s\n", genGetter(stName, field.Name, field.Type))
-						}
-					}
-
+				fail(err)
+				continue
+			}
+			if *all && !pg.Generated(typeName) {
+				// Nothing to generate, e.g. every field is excluded.
+				continue
+			}
+			inTest := pg.InTestFile(typeName)
+			if *singleFile || *output != "" {
+				if inTest {
+					generatedTests = append(generatedTests, typeName)
+				} else {
+					generated = append(generated, typeName)
 				}
+				continue
+			}
+			// AccessWrite to file.
+			outputName := ""
+			if *outputPattern != "" {
+				baseName, err := gen.OutputName(*outputPattern, typeName, pkg.Name())
+				if err != nil {
+					fail(fmt.Errorf("-output-pattern: %s", err))
+					continue
+				}
+				outputName = filepath.Join(pkg.Dir(), baseName)
+			}
+			if outputName == "" {
+				baseName := fmt.Sprintf("%s_accessor.go", typeName)
+				outputName = filepath.Join(pkg.Dir(), strings.ToLower(baseName))
+			}
+			if inTest {
+				outputName = testFileName(outputName, pkg)
+			}
+			if err := pg.Write(outputName, typeName); err != nil {
+				fail(err)
+			}
+		}
+		outputName := *output
+		if outputName == "" {
+			outputName = filepath.Join(pkg.Dir(), "accessors_gen.go")
+		}
+		if len(generated) > 0 {
+			if err := pg.Write(outputName, generated...); err != nil {
+				fail(err)
+			}
+		}
+		if len(generatedTests) > 0 {
+			if err := pg.Write(testFileName(outputName, pkg), generatedTests...); err != nil {
+				fail(err)
 			}
-
 		}
 	}
-
+	if len(errs) == 0 {
+		if err := g.SaveCache(); err != nil {
+			log.Printf("cache: %s", err)
+		}
+	}
+	for _, name := range g.Unchanged() {
+		log.Printf("%s is up to date", name)
+	}
+	for _, name := range g.Stale() {
+		fmt.Fprintf(os.Stderr, "%s is out of date\n", name)
+	}
+	return g, !summarize() && len(g.Stale()) == 0, nil
 }
 
-type StructFieldInfo struct {
-	Name   string
-	Type   string
-	Access []string
+// newGenerator returns a Generator configured from the command line flags.
+func newGenerator() *gen.Generator {
+	cfg := gen.Config{
+		Tag:             *tagName,
+		Embedded:        *embedded,
+		DeepCopy:        *deepCopy,
+		Clone:           *clone,
+		Equal:           *equal,
+		Stringer:        *stringer,
+		GoStringer:      *goStringer,
+		Slog:            *slogFlag,
+		Zap:             *zap,
+		Maps:            *maps,
+		ByName:          *byName,
+		FieldNames:      *fieldNames,
+		Fields:          *fieldInfo,
+		IsZero:          *isZero,
+		Reset:           *reset,
+		Merge:           *merge,
+		Diff:            *diff,
+		Patch:           *patch,
+		Constructor:     *constructor,
+		Safe:            *safe,
+		Funcs:           *funcs,
+		Unexported:      *unexported,
+		Audit:           *audit,
+		Builder:         *builder,
+		Options:         *options,
+		Immutable:       *immutable,
+		ThreadSafe:      *threadSafe,
+		NilSafe:         *nilSafe,
+		OkGetters:       *okGetters,
+		Defensive:       *defensive,
+		GetterPrefix:    *getterPrefix,
+		SetterPrefix:    *setterPrefix,
+		BoolPrefix:      *boolPrefix,
+		Initialisms:     strings.Split(*initialismsFlag, ","),
+		LegacyNames:     *legacyNames,
+		Receiver:        *receiver,
+		ReceiverKind:    *receiverKind,
+		Interface:       *iface,
+		Mock:            *mock,
+		View:            *view,
+		WithTests:       *withTests,
+		Chain:           *chain,
+		ErrSetters:      *errSetters,
+		TrackChanges:    *trackChanges,
+		Observers:       *observers,
+		SortFields:      *sortFields,
+		TemplateDir:     *templateDir,
+		Strict:          *strict,
+		OnConflict:      *onConflict,
+		Validate:        *validate,
+		IncludeTests:    *includeTests,
+		GOOS:            *goos,
+		GOARCH:          *goarch,
+		Check:           *check,
+		DryRun:          *dryRun,
+		Force:           *force,
+		Command:         strings.Join(append([]string{"accessor"}, headerArgs(os.Args[1:])...), " "),
+		Version:         version(),
+		BuildConstraint: *buildTags,
+	}
+	if *goStyle {
+		cfg.GetterPrefix = ""
+	}
+	if *headerFile != "" {
+		data, err := os.ReadFile(*headerFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Header = string(data)
+	}
+	for _, tag := range strings.Split(*loadTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cfg.BuildTags = append(cfg.BuildTags, tag)
+		}
+	}
+	if *cache && !*listTypes {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Cache = filepath.Join(dir, "accessor")
+		cfg.CacheKey = flagValues()
+	}
+	if *columns {
+		cfg.ColumnTag = *columnTag
+	}
+	g, err := gen.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return g
 }
-type StructFieldInfoArr = []StructFieldInfo
 
-func ParseStruct(file *ast.File, fileSet *token.FileSet, tagName string) (structMap map[string]StructFieldInfoArr, err error) {
-	structMap = make(map[string]StructFieldInfoArr)
+// errs are the errors collected during the run, without -strict.
+var errs []error
 
-	collectStructs := func(x ast.Node) bool {
-		ts, ok := x.(*ast.TypeSpec)
-		if !ok || ts.Type == nil {
-			return true
+// summarize prints the errors collected during the run followed by their
+// count, and reports whether there were any.
+func summarize() bool {
+	for _, err := range errs {
+		log.Print(err)
+	}
+	switch n := len(errs); {
+	case n == 1:
+		log.Print("1 error")
+	case n > 1:
+		log.Printf("%d errors", n)
+	}
+	return len(errs) > 0
+}
+
+// fail reports err. With -strict it stops at once, panicking with a
+// strictError that run returns and exitStrict exits with; otherwise the
+// error is collected and printed with the others at the end of the run, so
+// that the remaining types are still generated. Joined errors count one by
+// one.
+func fail(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			fail(err)
 		}
+		return
+	}
+	if errors.Is(err, gen.ErrNotGenerated) {
+		err = fmt.Errorf("%w; use -force to overwrite it", err)
+	}
+	if *strict {
+		panic(strictError{err})
+	}
+	errs = append(errs, err)
+}
 
-		// 获取结构体名称
-		structName := ts.Name.Name
+// strictError is the error stopping a run with -strict.
+type strictError struct {
+	err error
+}
 
-		s, ok := ts.Type.(*ast.StructType)
+// exitStrict exits with the error of a strictError panic, outside of run,
+// which returns it instead so that -watch goes on.
+func exitStrict() {
+	if r := recover(); r != nil {
+		s, ok := r.(strictError)
 		if !ok {
-			return true
+			panic(r)
 		}
-		fileInfos := make([]StructFieldInfo, 0)
-		for _, field := range s.Fields.List {
-			log.Println(field)
-			if len(field.Names) == 0 {
-				continue
-			}
-			name := field.Names[0].Name
-			info := StructFieldInfo{Name: name}
-			var typeNameBuf bytes.Buffer
-			err := printer.Fprint(&typeNameBuf, fileSet, field.Type)
-			if err != nil {
-				fmt.Println("获取类型失败:", err)
-				return true
-			}
+		log.Fatal(s.err)
+	}
+}
 
-			info.Type = typeNameBuf.String()
-			if field.Tag != nil { // 有tag
-				tag := field.Tag.Value
-				tag = strings.Trim(tag, "`")
-				tags, err := structtag.Parse(tag)
-				if err != nil {
-					return true
-				}
-				access, err := tags.Get(tagName)
-				if err == nil {
-					access.Options = append(access.Options, access.Name)
-					for i, v := range access.Options {
-						if v == AccessRead || v == AccessWrite {
-							continue
-						}
-						access.Options = append(access.Options[:i], access.Options[i+1:]...)
-					}
-				}
-				info.Access = access.Options
-			} else {
-				firstChar := name[0:1]
-				if strings.ToUpper(firstChar) == firstChar { //大写
-					info.Access = []string{AccessRead, AccessWrite}
-				} else { // 小写
-					info.Access = []string{AccessRead}
-				}
-			}
-			fileInfos = append(fileInfos, info)
+// excludeTypes returns names without the types in the comma-separated
+// list excluded.
+func excludeTypes(names []string, excluded string) []string {
+	skip := make(map[string]bool)
+	for _, typeName := range strings.Split(excluded, ",") {
+		skip[strings.TrimSpace(typeName)] = true
+	}
+	var kept []string
+	for _, typeName := range names {
+		if !skip[typeName] {
+			kept = append(kept, typeName)
 		}
-		structMap[structName] = fileInfos
-		return false
 	}
+	return kept
+}
 
-	ast.Inspect(file, collectStructs)
+// testFileName returns the name of the _test.go file holding the output
+// for the types declared in the test files of pkg that would otherwise go
+// to name. The output for an external test package gets a name of its own.
+func testFileName(name string, pkg *gen.Package) string {
+	if name == "-" {
+		return name
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	if strings.HasSuffix(pkg.Name(), "_test") {
+		name += "_external"
+	}
+	return name + "_test.go"
+}
 
-	return structMap, nil
+// headerArgs returns the command line arguments recorded in the header of
+// the output, leaving out the flags that don't affect it, so that -check
+// compares against the output of the same command without -check.
+func headerArgs(args []string) []string {
+	var recorded []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && unrecordedFlags[name] {
+			// Skip the value too when it is a separate argument.
+			if f := flag.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) {
+				i++
+			}
+			continue
+		}
+		recorded = append(recorded, arg)
+	}
+	return recorded
 }
 
-func genSetter(structName, fieldName, typeName string) string {
-	tpl := `func ({{.Receiver}} *{{.Struct}}) Set{{.Field}}(param {{.Type}}) {
-	{{.Receiver}}.{{.Field}} = param
-}`
-	t := template.New("setter")
-	t = template.Must(t.Parse(tpl))
-	res := bytes.NewBufferString("")
-	t.Execute(res, map[string]string{
-		"Receiver": strings.ToLower(structName[0:1]),
-		"Struct":   structName,
-		"Field":    fieldName,
-		"Type":     typeName,
-	})
-	return res.String()
+// isBoolFlag reports whether f is a boolean flag, which takes no separate
+// value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
 }
 
-func genGetter(structName, fieldName, typeName string) string {
-	tpl := `func ({{.Receiver}} *{{.Struct}}) Get{{.Field}}() {{.Type}} {
-	return {{.Receiver}}.{{.Field}}
-}`
-	t := template.New("getter")
-	t = template.Must(t.Parse(tpl))
-	res := bytes.NewBufferString("")
-	t.Execute(res, map[string]string{
-		"Receiver": strings.ToLower(structName[0:1]),
-		"Struct":   structName,
-		"Field":    fieldName,
-		"Type":     typeName,
+// unrecordedFlags are the flags left out of the output header.
+var unrecordedFlags = map[string]bool{"check": true, "dry-run": true, "watch": true, "jobs": true, "cache": true, "force": true}
+
+// flagValues returns the values of the flags that change the output, set
+// on the command line or in the configuration file, for the cache keys.
+func flagValues() string {
+	var b strings.Builder
+	flag.VisitAll(func(f *flag.Flag) {
+		if !unrecordedFlags[f.Name] {
+			fmt.Fprintf(&b, "-%s=%s\n", f.Name, f.Value)
+		}
 	})
-	return res.String()
+	return b.String()
 }
@@ -0,0 +1,14 @@
+// Package meta names the types of the values returned by the code
+// generated by the accessor command, which spells them out rather than
+// importing this package.
+package meta
+
+// FieldInfo describes a field with accessors of a struct type, as listed
+// by the Fields method generated with -fields. It is an alias so that the
+// elements of the generated []struct{...} are FieldInfo values.
+type FieldInfo = struct {
+	Name     string // name of the field as declared
+	Type     string // type of the field as written in the generated code
+	Readable bool   // the field has a getter
+	Writable bool   // the field has a setter or a With method
+}
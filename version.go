@@ -0,0 +1,36 @@
+package main
+
+import "runtime/debug"
+
+// version returns the version of the accessor command from its build
+// information: the module version when installed with go install, or else
+// the VCS revision it was built from, marked when the tree was modified.
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "(devel)"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "+dirty"
+	}
+	return "devel-" + revision
+}
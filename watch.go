@@ -0,0 +1,89 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lazypandatg/accessor/gen"
+)
+
+// settle is how long watchPackages waits after a change for more changes
+// before regenerating, so that saving several files runs only once.
+const settle = 200 * time.Millisecond
+
+// watchPackages runs the generation once and again whenever a Go file in
+// the directories of the packages changes, until the process is stopped.
+// Changes to the files the generator writes are ignored.
+func watchPackages(patterns, types []string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer watcher.Close()
+
+	written := make(map[string]bool) // files written by the last run
+	watchDirs := func(g *gen.Generator) {
+		for _, pkg := range g.Packages() {
+			if err := watcher.Add(pkg.Dir()); err != nil {
+				log.Print(err)
+			}
+		}
+		clear(written)
+		for _, name := range g.Written() {
+			written[name] = true
+		}
+	}
+	g, _, err := run(patterns, types)
+	if err != nil {
+		log.Print(err)
+	}
+	watchDirs(g)
+	log.Print("watching for changes")
+
+	var timer <-chan time.Time
+	var changed string
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) ||
+				written[event.Name] || !source(event.Name) {
+				continue
+			}
+			changed = event.Name
+			timer = time.After(settle)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Print(err)
+		case <-timer:
+			timer = nil
+			log.Printf("%s changed, regenerating", filepath.Base(changed))
+			g, _, err := run(patterns, types)
+			if err != nil {
+				log.Print(err)
+			}
+			// Packages may have been added under a ./... pattern.
+			watchDirs(g)
+		}
+	}
+}
+
+// source reports whether name is a Go file the generation depends on: not
+// a test, unless -include-tests is set, and not written by the generator.
+// A removed file counts.
+func source(name string) bool {
+	if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") && !*includeTests {
+		return false
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.PackageClauseOnly|parser.ParseComments)
+	return err != nil || !gen.IsGenerated(file)
+}